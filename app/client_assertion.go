@@ -0,0 +1,158 @@
+package app
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	. "nrf/conf"
+	. "nrf/logs"
+	"os"
+	"time"
+)
+
+const (
+	// client assertion type of private_key_jwt, as of RFC 7523
+	jwtBearerAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	// bounds client assertion lifetime, and so the replay cache
+	maxClientAssertionLifetime = time.Hour
+	clientAssertionLeeway      = 30 * time.Second
+)
+
+// ClientCredentialsAssertion are the claims of the CCA signed by NF consumers, as of TS 33.501
+type ClientCredentialsAssertion struct {
+	jwt.RegisteredClaims
+	NFType string `json:"nfType,omitempty"`
+}
+
+func loadClientCAs(caFile string) (pool *x509.CertPool, err error) {
+	caCert, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool = x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no CA certificate found in %s", caFile)
+	}
+	return pool, nil
+}
+
+func (nrf *NRF) checkClientAssertion(request *AccessTokenReq, now time.Time) (b bool, err error) {
+	b, err = true, nil
+	if nrf.clientCAs == nil {
+		return false, errors.New("client CA certificates are not loaded")
+	}
+	// verify CCA signature by the NF certificate chained to a client CA
+	var claims ClientCredentialsAssertion
+	var certificate *x509.Certificate
+	token, err := jwt.ParseWithClaims(request.ClientAssertion, &claims, func(token *jwt.Token) (interface{}, error) {
+		leaf, err := verifyCertificateChain(token.Header["x5c"], nrf.clientCAs, now)
+		if err != nil {
+			return nil, err
+		}
+		certificate = leaf
+		return certificate.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithTimeFunc(func() time.Time { return now }),
+		jwt.WithLeeway(clientAssertionLeeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil || !token.Valid {
+		return false, fmt.Errorf("client_assertion is invalid: %w", err)
+	}
+	// CCA identifies the requester
+	if claims.Subject != request.NFInstanceId {
+		return false, errors.New("client_assertion sub does not match nfInstanceId")
+	}
+	// NF certificate shall be issued to the same NF instance
+	if !matchCertificateNFInstanceId(certificate, claims.Subject) || !matchCertificateNFInstanceId(certificate, request.NFInstanceId) {
+		return false, errors.New("client_assertion x5c certificate does not identify sub")
+	}
+	if claims.NFType != "" && request.NFType != "" && claims.NFType != request.NFType {
+		return false, errors.New("client_assertion nfType does not match nfType")
+	}
+	audience := false
+	for _, v := range claims.Audience {
+		if v == "NRF" || v == NRFConfigure.NRFInstanceId {
+			audience = true
+			break
+		}
+	}
+	if !audience {
+		return false, errors.New("client_assertion audience is not the NRF")
+	}
+	// replay protection, jti is remembered until the assertion expires
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return false, errors.New("client_assertion jti and iat are mandatory")
+	}
+	if claims.ExpiresAt.Sub(claims.IssuedAt.Time) > maxClientAssertionLifetime {
+		return false, fmt.Errorf("client_assertion lifetime exceeds %s", maxClientAssertionLifetime)
+	}
+	if !nrf.recordAssertion(claims.Subject+"/"+claims.ID, claims.ExpiresAt.Add(clientAssertionLeeway)) {
+		return false, errors.New("client_assertion jti is replayed")
+	}
+	return b, err
+}
+
+func verifyCertificateChain(x5c interface{}, roots *x509.CertPool, now time.Time) (certificate *x509.Certificate, err error) {
+	// x5c carries the NF certificate first, then its intermediates
+	chain, ok := x5c.([]interface{})
+	if !ok || len(chain) == 0 {
+		return nil, errors.New("x5c header is missing")
+	}
+	intermediates := x509.NewCertPool()
+	for i, v := range chain {
+		encoded, ok := v.(string)
+		if !ok {
+			return nil, errors.New("x5c header is invalid")
+		}
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("x5c header is invalid: %w", err)
+		}
+		parsed, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("x5c header is invalid: %w", err)
+		}
+		if i == 0 {
+			certificate = parsed
+			continue
+		}
+		intermediates.AddCert(parsed)
+	}
+	_, err = certificate.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+func (nrf *NRF) recordAssertion(key string, expiry time.Time) bool {
+	nrf.assertionsMutex.Lock()
+	defer nrf.assertionsMutex.Unlock()
+	if _, exists := nrf.assertions[key]; exists {
+		return false
+	}
+	nrf.assertions[key] = expiry
+	return true
+}
+
+func (nrf *NRF) purgeAssertions(now time.Time) {
+	// drop replay cache entries of expired client assertions
+	nrf.assertionsMutex.Lock()
+	defer nrf.assertionsMutex.Unlock()
+	for k, v := range nrf.assertions {
+		if !v.After(now) {
+			L.Debug("Client assertion expired:", k)
+			delete(nrf.assertions, k)
+		}
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"io"
 	"net/http"
+	. "nrf/data"
+	. "nrf/logs"
 	"strings"
+	"time"
 )
 
 type ETagConfig struct {
@@ -23,41 +26,81 @@ var defaultConfig = ETagConfig{
 	CacheMaxAge:    3600,
 }
 
-func AuthorizationMiddleware() gin.HandlerFunc {
+type AuthorizationConfig struct {
+	Issuer     string            // 签发访问令牌的NRF实例
+	Audiences  []string          // 接受的令牌受众
+	Scopes     map[string]string // API名称所需的令牌范围
+	Exemptions []string          // 免除令牌的路由 "<METHOD> <path>" 或 "<path>"
+	Owners     map[string]string // 路由 "<METHOD> <path>" 中须等于令牌主体的路径参数
+}
+
+func (nrf *NRF) AuthorizationMiddleware(config AuthorizationConfig) gin.HandlerFunc {
 	return func(context *gin.Context) {
+		// skip exempted routes, unknown routes are matched by path
+		route := context.FullPath()
+		if route == "" {
+			route = context.Request.URL.Path
+		}
+		if matchExemptions(config.Exemptions, context.Request.Method, route) {
+			context.Next()
+			return
+		}
 		// get auth token header
 		authHeader := context.GetHeader("Authorization")
 		if authHeader == "" {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "authorization_header_missing",
-			})
+			abortAuthorization(context, http.StatusUnauthorized, "", "Authorization header missing", "")
 			return
 		}
 		// extract bearer token
 		tokenString := extractBearerToken(authHeader)
 		if tokenString == "" {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid_authorization_header",
-			})
+			abortAuthorization(context, http.StatusBadRequest, "invalid_request", "Authorization header is not a Bearer token", "")
 			return
 		}
-		// parse and verify token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		// parse and verify token signature, issuer and expiry
+		var claims AccessTokenClaims
+		token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+			// verify by the published key of the token kid
+			kid, _ := token.Header["kid"].(string)
+			key := nrf.signingKeys.verificationKey(kid, time.Now())
+			if key == nil {
+				return nil, fmt.Errorf("unknown signing key: %v", token.Header["kid"])
+			}
+			if token.Method.Alg() != key.method.Alg() {
 				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 			}
-			return oauthConfig.PublicKey, nil
-		})
+			return key.signer.Public(), nil
+		}, jwt.WithIssuer(config.Issuer), jwt.WithExpirationRequired())
 		if err != nil || !token.Valid {
-			context.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid_token",
-			})
+			abortAuthorization(context, http.StatusUnauthorized, "invalid_token", fmt.Sprint("Access token is invalid: ", err), "")
 			return
 		}
-		// set token context information
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			context.Set("clientID", claims["sub"])
+		// verify token audience
+		audience := false
+		for _, v := range claims.Audience {
+			if containsString(config.Audiences, v) {
+				audience = true
+				break
+			}
+		}
+		if !audience {
+			abortAuthorization(context, http.StatusUnauthorized, "invalid_token", "Access token audience is not accepted", "")
+			return
 		}
+		// verify scope required by the API name, the first path segment
+		apiName, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		scope, exists := config.Scopes[apiName]
+		if exists && !containsString(strings.Fields(claims.Scope), scope) {
+			abortAuthorization(context, http.StatusForbidden, "insufficient_scope", "Access token scope is insufficient", scope)
+			return
+		}
+		// verify token subject owns the resource identified by the route parameter
+		if param, exists := config.Owners[context.Request.Method+" "+route]; exists && !strings.EqualFold(context.Param(param), claims.Subject) {
+			abortAuthorization(context, http.StatusForbidden, "insufficient_scope", "Access token subject does not own the resource", "")
+			return
+		}
+		// set token context information
+		context.Set("clientID", claims.Subject)
 		context.Next()
 	}
 }
@@ -152,29 +195,30 @@ func ETagMiddleware(config ETagConfig) gin.HandlerFunc {
 			context.Next()
 			return
 		}
-		// pre-check client ETag
-		clientETag := context.GetHeader("If-None-Match")
+		// buffer response until handler ETag known
+		writer := &ETagResponseWriter{ResponseWriter: context.Writer}
+		context.Writer = writer
 		context.Next()
-		// get response content
-		respBody, exists := context.Get("responseBody")
-		if !exists || respBody == nil {
-			return
-		}
-		// generate ETag
-		content := respBody.([]byte)
-		etag := generateETag(content, config.WeakValidation)
-		// set response header
-		context.Header("ETag", etag)
-		context.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", config.CacheMaxAge))
-		// verify client ETag
-		if clientETag != "" {
-			if compareETags(clientETag, etag, config.WeakValidation) {
-				context.AbortWithStatus(http.StatusNotModified)
+		context.Writer = writer.ResponseWriter
+		// get ETag set by handler
+		etag := writer.Header().Get("ETag")
+		if etag != "" && writer.Status() == http.StatusOK {
+			if writer.Header().Get("Cache-Control") == "" {
+				writer.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", config.CacheMaxAge))
+			}
+			// verify client ETag
+			clientETag := context.GetHeader("If-None-Match")
+			if clientETag != "" && matchETags(clientETag, etag, true) {
+				writer.Header().Del("Content-Type")
+				writer.ResponseWriter.WriteHeader(http.StatusNotModified)
+				writer.ResponseWriter.WriteHeaderNow()
 				return
 			}
 		}
 		// send response
-		context.Data(http.StatusOK, context.Writer.Header().Get("Content-Type"), content)
+		if writer.body.Len() != 0 {
+			_, _ = writer.ResponseWriter.Write(writer.body.Bytes())
+		}
 	}
 }
 
@@ -189,12 +233,12 @@ func generateETag(data []byte, weak bool) string {
 func compareETags(clientTag, serverTag string, weakCompare bool) bool {
 	// clean ETag
 	cleanTag := func(t string) string {
-		if len(t) >= 2 && t[0] == '"' {
-			t = t[1 : len(t)-1]
-		}
 		if len(t) > 2 && t[:2] == "W/" {
 			t = t[2:]
 		}
+		if len(t) >= 2 && t[0] == '"' {
+			t = t[1 : len(t)-1]
+		}
 		return t
 	}
 	// clean client and server ETag
@@ -204,7 +248,46 @@ func compareETags(clientTag, serverTag string, weakCompare bool) bool {
 	if weakCompare {
 		return client == server
 	}
-	return client == server && !strings.HasPrefix(clientTag, "W/")
+	return client == server && !strings.HasPrefix(clientTag, "W/") && !strings.HasPrefix(serverTag, "W/")
+}
+
+func matchETags(header, serverTag string, weakCompare bool) bool {
+	// match comma separated ETag list or "*"
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || compareETags(v, serverTag, weakCompare) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchExemptions(exemptions []string, method string, route string) bool {
+	for _, v := range exemptions {
+		if v == route || v == method+" "+route {
+			return true
+		}
+	}
+	return false
+}
+
+func abortAuthorization(context *gin.Context, status int, errorCode string, description string, scope string) {
+	// challenge as of RFC 6750, no error code when the request lacks authentication
+	challenge := `Bearer realm="NRF"`
+	if errorCode != "" {
+		challenge += fmt.Sprintf(`, error="%s", error_description="%s"`, errorCode, strings.ReplaceAll(description, `"`, "'"))
+	}
+	if scope != "" {
+		challenge += fmt.Sprintf(`, scope="%s"`, scope)
+	}
+	var problemDetails ProblemDetails
+	problemDetails.Title = http.StatusText(status)
+	problemDetails.Status = status
+	problemDetails.Detail = description
+	context.Header("WWW-Authenticate", challenge)
+	context.Header("Content-Type", "application/problem+json")
+	context.AbortWithStatusJSON(status, problemDetails)
+	L.Error("Authorization failed:", context.Request.Method, context.Request.URL.Path, description)
 }
 
 func extractBearerToken(header string) string {
@@ -214,6 +297,19 @@ func extractBearerToken(header string) string {
 	return ""
 }
 
+type ETagResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *ETagResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *ETagResponseWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
 type GzipResponseWriter struct {
 	gin.ResponseWriter
 	compressWriter *gzip.Writer
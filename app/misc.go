@@ -1,14 +1,22 @@
 package app
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"hash/fnv"
+	"math/rand"
 	"net"
 	"net/http"
+	. "nrf/conf"
 	. "nrf/data"
 	. "nrf/logs"
 	. "nrf/util"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func checkNFRegisterIEs(request *NFProfile) (b bool, err error) {
@@ -53,6 +61,321 @@ func checkNFRegisterIEs(request *NFProfile) (b bool, err error) {
 		}
 	}
 	L.Debug("CheckHeartBeatTimer success.")
+	// check AllowedNfTypes
+	L.Debug("Start CheckAllowedNfTypes:", request.AllowedNfTypes)
+	for _, v := range request.AllowedNfTypes {
+		b, err = CheckNFType(v)
+		if err != nil {
+			b = false
+			L.Error("CheckAllowedNfTypes failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckAllowedNfTypes success.")
+	// check AllowedNfDomains
+	L.Debug("Start CheckAllowedNfDomains:", request.AllowedNfDomains)
+	for _, v := range request.AllowedNfDomains {
+		b, err = CheckAllowedNfDomain(v)
+		if err != nil {
+			b = false
+			L.Error("CheckAllowedNfDomains failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckAllowedNfDomains success.")
+	// check NfSetIdList
+	L.Debug("Start CheckNfSetIdList:", request.NfSetIdList)
+	for _, v := range request.NfSetIdList {
+		b, err = CheckNfSetId(v)
+		if err != nil {
+			b = false
+			L.Error("CheckNfSetIdList failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckNfSetIdList success.")
+	// check NFInfo
+	L.Debug("Start CheckNFInfoTypes:", request.NFType)
+	b, err = CheckNFInfoTypes(request.NFType, collectNFInfoTypes(request))
+	if err != nil {
+		b = false
+		L.Error("CheckNFInfoTypes failed:", err)
+		return b, err
+	}
+	L.Debug("CheckNFInfoTypes success.")
+	// check IdentityRanges and RoutingIndicators
+	identities := collectNFIdentities(request)
+	L.Debug("Start CheckIdentityRanges.")
+	for _, v := range append(append(append([]IdentityRange(nil), identities.supiRanges...), identities.gpsiRanges...), identities.extGroupIdRanges...) {
+		b, err = CheckIdentityRange(v)
+		if err != nil {
+			b = false
+			L.Error("CheckIdentityRanges failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckIdentityRanges success.")
+	L.Debug("Start CheckRoutingIndicators:", identities.routingIndicators)
+	for _, v := range identities.routingIndicators {
+		b, err = CheckRoutingIndicator(v)
+		if err != nil {
+			b = false
+			L.Error("CheckRoutingIndicators failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckRoutingIndicators success.")
+	// check TaiList and TaiRangeList
+	tais, taiRanges := collectNFTais(request)
+	L.Debug("Start CheckTais:", tais, taiRanges)
+	for _, v := range tais {
+		b, err = CheckTai(v)
+		if err != nil {
+			b = false
+			L.Error("CheckTais failed:", err)
+			return b, err
+		}
+	}
+	for _, v := range taiRanges {
+		b, err = CheckTaiRange(v)
+		if err != nil {
+			b = false
+			L.Error("CheckTais failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckTais success.")
+	// check NFServices
+	serviceInstanceIds := make(map[string]struct{})
+	for _, v := range request.NFServices {
+		b, err = checkNFServiceIEs(&v)
+		if err != nil {
+			return b, err
+		}
+		if _, exists := serviceInstanceIds[v.ServiceInstanceId]; exists {
+			b, err = false, errors.New("ServiceInstanceId is duplicated: "+v.ServiceInstanceId)
+			L.Error("CheckNFServices failed:", err)
+			return b, err
+		}
+		serviceInstanceIds[v.ServiceInstanceId] = struct{}{}
+	}
+	return b, err
+}
+
+func collectNFInfoTypes(profile *NFProfile) (infoTypes []string) {
+	// collect NF types of the info blocks carried in profile
+	if profile.AmfInfo != nil || len(profile.AmfInfoList) != 0 {
+		infoTypes = append(infoTypes, "AMF")
+	}
+	if profile.SmfInfo != nil || len(profile.SmfInfoList) != 0 {
+		infoTypes = append(infoTypes, "SMF")
+	}
+	if profile.UdmInfo != nil || len(profile.UdmInfoList) != 0 {
+		infoTypes = append(infoTypes, "UDM")
+	}
+	if profile.AusfInfo != nil || len(profile.AusfInfoList) != 0 {
+		infoTypes = append(infoTypes, "AUSF")
+	}
+	if profile.UpfInfo != nil || len(profile.UpfInfoList) != 0 {
+		infoTypes = append(infoTypes, "UPF")
+	}
+	if profile.PcfInfo != nil || len(profile.PcfInfoList) != 0 {
+		infoTypes = append(infoTypes, "PCF")
+	}
+	if profile.BsfInfo != nil || len(profile.BsfInfoList) != 0 {
+		infoTypes = append(infoTypes, "BSF")
+	}
+	if profile.ChfInfo != nil || len(profile.ChfInfoList) != 0 {
+		infoTypes = append(infoTypes, "CHF")
+	}
+	if profile.NwdafInfo != nil || len(profile.NwdafInfoList) != 0 {
+		infoTypes = append(infoTypes, "NWDAF")
+	}
+	return infoTypes
+}
+
+type nfIdentities struct {
+	supiRanges        []IdentityRange
+	gpsiRanges        []IdentityRange
+	extGroupIdRanges  []IdentityRange
+	routingIndicators []string
+	groupIds          []string
+}
+
+func collectNFIdentities(profile *NFProfile) (identities nfIdentities) {
+	// collect subscriber identities served by udmInfo, ausfInfo, pcfInfo and chfInfo
+	var udmInfos []UdmInfo
+	if profile.UdmInfo != nil {
+		udmInfos = append(udmInfos, *profile.UdmInfo)
+	}
+	for _, v := range profile.UdmInfoList {
+		udmInfos = append(udmInfos, v)
+	}
+	for _, v := range udmInfos {
+		for _, j := range v.SupiRanges {
+			identities.supiRanges = append(identities.supiRanges, IdentityRange(j))
+		}
+		identities.gpsiRanges = append(identities.gpsiRanges, v.GpsiRanges...)
+		identities.extGroupIdRanges = append(identities.extGroupIdRanges, v.ExternalGroupIdentifiersRanges...)
+		identities.routingIndicators = append(identities.routingIndicators, v.RoutingIndicators...)
+		identities.groupIds = append(identities.groupIds, v.GroupId)
+	}
+	var ausfInfos []AusfInfo
+	if profile.AusfInfo != nil {
+		ausfInfos = append(ausfInfos, *profile.AusfInfo)
+	}
+	for _, v := range profile.AusfInfoList {
+		ausfInfos = append(ausfInfos, v)
+	}
+	for _, v := range ausfInfos {
+		for _, j := range v.SupiRanges {
+			identities.supiRanges = append(identities.supiRanges, IdentityRange(j))
+		}
+		identities.routingIndicators = append(identities.routingIndicators, v.RoutingIndicators...)
+		identities.groupIds = append(identities.groupIds, v.GroupId)
+	}
+	var pcfInfos []PcfInfo
+	if profile.PcfInfo != nil {
+		pcfInfos = append(pcfInfos, *profile.PcfInfo)
+	}
+	for _, v := range profile.PcfInfoList {
+		pcfInfos = append(pcfInfos, v)
+	}
+	for _, v := range pcfInfos {
+		for _, j := range v.SupiRanges {
+			identities.supiRanges = append(identities.supiRanges, IdentityRange(j))
+		}
+		identities.gpsiRanges = append(identities.gpsiRanges, v.GpsiRanges...)
+		identities.groupIds = append(identities.groupIds, v.GroupId)
+	}
+	var chfInfos []ChfInfo
+	if profile.ChfInfo != nil {
+		chfInfos = append(chfInfos, *profile.ChfInfo)
+	}
+	for _, v := range profile.ChfInfoList {
+		chfInfos = append(chfInfos, v)
+	}
+	for _, v := range chfInfos {
+		for _, j := range v.SupiRangeList {
+			identities.supiRanges = append(identities.supiRanges, IdentityRange(j))
+		}
+		identities.gpsiRanges = append(identities.gpsiRanges, v.GpsiRangeList...)
+		identities.groupIds = append(identities.groupIds, v.GroupId)
+	}
+	return identities
+}
+
+func collectNFTais(profile *NFProfile) (tais []Tai, taiRanges []TaiRange) {
+	// collect tracking areas served by amfInfo, smfInfo and upfInfo
+	var amfInfos []AmfInfo
+	if profile.AmfInfo != nil {
+		amfInfos = append(amfInfos, *profile.AmfInfo)
+	}
+	for _, v := range profile.AmfInfoList {
+		amfInfos = append(amfInfos, v)
+	}
+	for _, v := range amfInfos {
+		tais = append(tais, v.TaiList...)
+		taiRanges = append(taiRanges, v.TaiRangeList...)
+	}
+	var smfInfos []SmfInfo
+	if profile.SmfInfo != nil {
+		smfInfos = append(smfInfos, *profile.SmfInfo)
+	}
+	for _, v := range profile.SmfInfoList {
+		smfInfos = append(smfInfos, v)
+	}
+	for _, v := range smfInfos {
+		tais = append(tais, v.TaiList...)
+		taiRanges = append(taiRanges, v.TaiRangeList...)
+	}
+	var upfInfos []UpfInfo
+	if profile.UpfInfo != nil {
+		upfInfos = append(upfInfos, *profile.UpfInfo)
+	}
+	for _, v := range profile.UpfInfoList {
+		upfInfos = append(upfInfos, v)
+	}
+	for _, v := range upfInfos {
+		tais = append(tais, v.TaiList...)
+		taiRanges = append(taiRanges, v.TaiRangeList...)
+	}
+	return tais, taiRanges
+}
+
+func checkNFServiceIEs(request *NFService) (b bool, err error) {
+	b, err = true, nil
+	// check mandatory IEs...
+	// check ServiceName
+	L.Debug("Start CheckServiceName:", request.ServiceName)
+	b, err = CheckServiceName(request.ServiceName)
+	if err != nil {
+		b = false
+		L.Error("CheckServiceName failed:", err)
+		return b, err
+	}
+	L.Debug("CheckServiceName success.")
+	// check Scheme
+	L.Debug("Start CheckScheme:", request.Scheme)
+	b, err = CheckScheme(request.Scheme)
+	if err != nil {
+		b = false
+		L.Error("CheckScheme failed:", err)
+		return b, err
+	}
+	L.Debug("CheckScheme success.")
+	// check Versions
+	L.Debug("Start CheckNFServiceVersions:", request.Versions)
+	b, err = CheckNFServiceVersions(request.Versions)
+	if err != nil {
+		b = false
+		L.Error("CheckNFServiceVersions failed:", err)
+		return b, err
+	}
+	L.Debug("CheckNFServiceVersions success.")
+	// check conditional IEs...
+	// check AllowedNfTypes
+	L.Debug("Start CheckServiceAllowedNfTypes:", request.AllowedNfTypes)
+	for _, v := range request.AllowedNfTypes {
+		b, err = CheckNFType(v)
+		if err != nil {
+			b = false
+			L.Error("CheckServiceAllowedNfTypes failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckServiceAllowedNfTypes success.")
+	// check AllowedNfDomains
+	L.Debug("Start CheckServiceAllowedNfDomains:", request.AllowedNfDomains)
+	for _, v := range request.AllowedNfDomains {
+		b, err = CheckAllowedNfDomain(v)
+		if err != nil {
+			b = false
+			L.Error("CheckServiceAllowedNfDomains failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckServiceAllowedNfDomains success.")
+	// check NfServiceSetIdList
+	L.Debug("Start CheckNfServiceSetIdList:", request.NfServiceSetIdList)
+	for _, v := range request.NfServiceSetIdList {
+		b, err = CheckNfServiceSetId(v)
+		if err != nil {
+			b = false
+			L.Error("CheckNfServiceSetIdList failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckNfServiceSetIdList success.")
+	// check SupportedFeatures
+	L.Debug("Start CheckSupportedFeatures:", request.SupportedFeatures)
+	b, err = CheckSupportedFeatures(request.SupportedFeatures)
+	if err != nil {
+		b = false
+		L.Error("CheckSupportedFeatures failed:", err)
+		return b, err
+	}
+	L.Debug("CheckSupportedFeatures success.")
 	return b, err
 }
 
@@ -74,9 +397,35 @@ func handleNFRegisterIEs(request *NFProfile) (err error) {
 		return err
 	}
 	L.Debug("HandleHeartBeatTimer success.")
+	// handle NrfSupportedFeatures, which is only set by NRF in responses
+	request.NrfSupportedFeatures = ""
 	return err
 }
 
+func checkNFUpdatePatchItems(request []PatchItem) (b bool, err error) {
+	b, err = true, nil
+	// check immutable IEs not modified
+	for _, item := range request {
+		if item.Op == "test" {
+			continue
+		}
+		paths := []string{item.Path}
+		if item.Op == "move" {
+			paths = append(paths, item.From)
+		}
+		for _, path := range paths {
+			L.Debug("Start CheckNFUpdatePatchPath:", item.Op, path)
+			if path == "" || path == "/nfInstanceId" || path == "/nfType" {
+				b, err = false, fmt.Errorf("modification of %q not allowed", path)
+				L.Error("CheckNFUpdatePatchPath failed:", err)
+				return b, err
+			}
+		}
+	}
+	L.Debug("CheckNFUpdatePatchPath success.")
+	return b, err
+}
+
 func checkNFRegisterSharedDataIEs(request *SharedData) (b bool, err error) {
 	b, err = true, nil
 	// check mandatory IEs...
@@ -105,17 +454,102 @@ func handleNFRegisterSharedDataIEs(request *SharedData) (err error) {
 	return err
 }
 
-func matchFeatures(required, supported []string) bool {
-	supportedSet := make(map[string]struct{})
-	for _, s := range supported {
-		supportedSet[s] = struct{}{}
+func checkNFStatusSubscribeIEs(request *SubscriptionData) (b bool, err error) {
+	b, err = true, nil
+	// check conditional IEs...
+	// check ReqNFType
+	L.Debug("Start CheckReqNFType:", request.ReqNFType)
+	if request.ReqNFType != "" {
+		b, err = CheckNFType(request.ReqNFType)
+		if err != nil {
+			b = false
+			L.Error("CheckReqNFType failed:", err)
+			return b, err
+		}
 	}
-	for _, r := range required {
-		if _, exist := supportedSet[r]; !exist {
-			return false
+	L.Debug("CheckReqNFType success.")
+	// check SubscrCond
+	L.Debug("Start CheckSubscrCond:", request.SubscrCond)
+	if request.SubscrCond != nil {
+		conditions := 0
+		if request.SubscrCond.NFInstanceId != "" {
+			conditions++
+		}
+		if request.SubscrCond.NFType != "" {
+			b, err = CheckNFType(request.SubscrCond.NFType)
+			if err != nil {
+				b = false
+				L.Error("CheckSubscrCond failed:", err)
+				return b, err
+			}
+			conditions++
+		}
+		if request.SubscrCond.ServiceName != "" {
+			conditions++
+		}
+		if conditions != 1 {
+			b, err = false, errors.New("SubscrCond should contain exactly one condition")
+			L.Error("CheckSubscrCond failed:", err)
+			return b, err
 		}
 	}
-	return true
+	L.Debug("CheckSubscrCond success.")
+	// check ValidityTime
+	L.Debug("Start CheckValidityTime:", request.ValidityTime)
+	if request.ValidityTime != nil && !request.ValidityTime.After(time.Now()) {
+		b, err = false, errors.New("ValidityTime should be in the future")
+		L.Error("CheckValidityTime failed:", err)
+		return b, err
+	}
+	L.Debug("CheckValidityTime success.")
+	return b, err
+}
+
+func handleNFStatusSubscribeIEs(request *SubscriptionData) (err error) {
+	err = nil
+	// handle SubscriptionId
+	request.SubscriptionId = uuid.New().String()
+	L.Debug("HandleSubscriptionId success:", request.SubscriptionId)
+	// handle SubscrCond
+	if request.SubscrCond != nil {
+		err = HandleNFInstanceId(&request.SubscrCond.NFInstanceId)
+		if err != nil {
+			L.Error("HandleSubscrCond failed:", err)
+			return err
+		}
+	}
+	// handle ValidityTime
+	L.Debug("Start HandleValidityTime:", request.ValidityTime)
+	maxValidityTime := time.Now().Add(time.Duration(NRFConfigure.SubscriptionValidityTime) * time.Second).UTC()
+	if request.ValidityTime == nil || request.ValidityTime.After(maxValidityTime) {
+		request.ValidityTime = &maxValidityTime
+	}
+	L.Debug("HandleValidityTime success:", request.ValidityTime)
+	return err
+}
+
+var errPreconditionFailed = errors.New("If-Match precondition failed")
+
+func formETag(resource interface{}) (etag string) {
+	// ETag is the content hash of the stored resource
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return ""
+	}
+	return generateETag(data, defaultConfig.WeakValidation)
+}
+
+func matchIfMatch(context *gin.Context, etag string) bool {
+	// no precondition when If-Match absent
+	ifMatch := context.GetHeader("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+	// "*" matches any current representation, none when resource not exists
+	if etag == "" {
+		return false
+	}
+	return matchETags(ifMatch, etag, defaultConfig.WeakValidation)
 }
 
 func autodetectHttpProtocol(context *gin.Context) (protocol string) {
@@ -166,11 +600,20 @@ func formLocation(context *gin.Context, apiName string, apiVersion string, resou
 	return fmt.Sprintf("%s://%s/%s/%s/%s/%s", autodetectHttpProtocol(context), autodetectHttpHost(context), apiName, apiVersion, resource, identity)
 }
 
+func formConfiguredLocation(apiName string, apiVersion string, resource string, identity string) (location string) {
+	// form location from NRF configure when no request context available
+	protocol := "https"
+	if NRFConfigure.SBITLSSettings.TLSType == "non-tls" {
+		protocol = "http"
+	}
+	return fmt.Sprintf("%s://%s:%d/%s/%s/%s/%s", protocol, NRFConfigure.SBIIPAddr, NRFConfigure.SBIPort, apiName, apiVersion, resource, identity)
+}
+
 func handleNFListRetrieveQuery(request *NFListRetrieveRequest) {
-	// handle Limit
+	// handle Limit, 0 means no limit
 	L.Debug("Start HandleLimit", request.Limit)
-	if request.Limit == 0 {
-		request.Limit = 1
+	if request.Limit < 0 {
+		request.Limit = 0
 	}
 	L.Debug("HandleLimit success:", request.Limit)
 	// handle HandlePageNumber
@@ -178,12 +621,487 @@ func handleNFListRetrieveQuery(request *NFListRetrieveRequest) {
 	if request.PageNumber == 0 {
 		request.PageNumber = 1
 	}
-	L.Debug("HandlePageSize success.")
-	// handle HandlePageSize
+	L.Debug("HandlePageNumber success.")
+	// handle HandlePageSize, 0 means the whole list in one page
 	L.Debug("Start HandlePageSize:", request.PageSize)
-	if request.PageSize == 0 {
-		request.PageSize = 1
+	if request.PageSize < 0 {
+		request.PageSize = 0
 	}
 	L.Debug("HandlePageSize success.")
 	return
 }
+
+func checkNFDiscoverIEs(request *NFDiscoverRequest) (b bool, err error) {
+	b, err = true, nil
+	// check mandatory IEs...
+	// check TargetNFType
+	L.Debug("Start CheckTargetNFType:", request.TargetNFType)
+	b, err = CheckNFType(request.TargetNFType)
+	if err != nil {
+		b = false
+		L.Error("CheckTargetNFType failed:", err)
+		return b, err
+	}
+	L.Debug("CheckTargetNFType success.")
+	// check RequesterNFType
+	L.Debug("Start CheckRequesterNFType:", request.RequesterNFType)
+	b, err = CheckNFType(request.RequesterNFType)
+	if err != nil {
+		b = false
+		L.Error("CheckRequesterNFType failed:", err)
+		return b, err
+	}
+	L.Debug("CheckRequesterNFType success.")
+	// check conditional IEs...
+	// check ServiceNames
+	L.Debug("Start CheckServiceNames:", request.ServiceNames)
+	for _, v := range request.ServiceNames {
+		b, err = CheckServiceName(v)
+		if err != nil {
+			b = false
+			L.Error("CheckServiceNames failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckServiceNames success.")
+	// check RequesterNFInstanceId
+	if request.RequesterNFInstanceId != "" {
+		L.Debug("Start CheckRequesterNFInstanceId:", request.RequesterNFInstanceId)
+		b, err = CheckNFInstanceId(request.RequesterNFInstanceId)
+		if err != nil {
+			b = false
+			L.Error("CheckRequesterNFInstanceId failed:", err)
+			return b, err
+		}
+		L.Debug("CheckRequesterNFInstanceId success.")
+	}
+	// check TargetNFInstanceId
+	if request.TargetNFInstanceId != "" {
+		L.Debug("Start CheckTargetNFInstanceId:", request.TargetNFInstanceId)
+		b, err = CheckNFInstanceId(request.TargetNFInstanceId)
+		if err != nil {
+			b = false
+			L.Error("CheckTargetNFInstanceId failed:", err)
+			return b, err
+		}
+		L.Debug("CheckTargetNFInstanceId success.")
+	}
+	// check SNssais
+	L.Debug("Start CheckSNssais:", request.snssais, request.requesterSnssais)
+	for _, v := range append(append([]Snssai(nil), request.snssais...), request.requesterSnssais...) {
+		b, err = CheckSnssai(v)
+		if err != nil {
+			b = false
+			L.Error("CheckSNssais failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckSNssais success.")
+	// check PlmnLists
+	L.Debug("Start CheckPlmnLists:", request.targetPlmnList, request.requesterPlmnList)
+	for _, v := range append(append([]PlmnId(nil), request.targetPlmnList...), request.requesterPlmnList...) {
+		b, err = CheckPlmnId(v)
+		if err != nil {
+			b = false
+			L.Error("CheckPlmnLists failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckPlmnLists success.")
+	// check Supi
+	if request.Supi != "" {
+		L.Debug("Start CheckSupi:", request.Supi)
+		b, err = CheckSupi(request.Supi)
+		if err != nil {
+			b = false
+			L.Error("CheckSupi failed:", err)
+			return b, err
+		}
+		L.Debug("CheckSupi success.")
+	}
+	// check Gpsi
+	if request.Gpsi != "" {
+		L.Debug("Start CheckGpsi:", request.Gpsi)
+		b, err = CheckGpsi(request.Gpsi)
+		if err != nil {
+			b = false
+			L.Error("CheckGpsi failed:", err)
+			return b, err
+		}
+		L.Debug("CheckGpsi success.")
+	}
+	// check ExternalGroupIdentity
+	if request.ExternalGroupIdentity != "" {
+		L.Debug("Start CheckExternalGroupIdentity:", request.ExternalGroupIdentity)
+		b, err = CheckExternalGroupId(request.ExternalGroupIdentity)
+		if err != nil {
+			b = false
+			L.Error("CheckExternalGroupIdentity failed:", err)
+			return b, err
+		}
+		L.Debug("CheckExternalGroupIdentity success.")
+	}
+	// check RoutingIndicator
+	if request.RoutingIndicator != "" {
+		L.Debug("Start CheckRoutingIndicator:", request.RoutingIndicator)
+		b, err = CheckRoutingIndicator(request.RoutingIndicator)
+		if err != nil {
+			b = false
+			L.Error("CheckRoutingIndicator failed:", err)
+			return b, err
+		}
+		L.Debug("CheckRoutingIndicator success.")
+	}
+	// check Tai
+	if request.tai != nil {
+		L.Debug("Start CheckTai:", *request.tai)
+		b, err = CheckTai(*request.tai)
+		if err != nil {
+			b = false
+			L.Error("CheckTai failed:", err)
+			return b, err
+		}
+		L.Debug("CheckTai success.")
+	}
+	// check Guami
+	if request.guami != nil {
+		L.Debug("Start CheckGuami:", *request.guami)
+		b, err = CheckGuami(*request.guami)
+		if err != nil {
+			b = false
+			L.Error("CheckGuami failed:", err)
+			return b, err
+		}
+		L.Debug("CheckGuami success.")
+	}
+	// check AmfRegionId
+	if request.AmfRegionId != "" {
+		L.Debug("Start CheckAmfRegionId:", request.AmfRegionId)
+		b, err = CheckAmfRegionId(request.AmfRegionId)
+		if err != nil {
+			b = false
+			L.Error("CheckAmfRegionId failed:", err)
+			return b, err
+		}
+		L.Debug("CheckAmfRegionId success.")
+	}
+	// check AmfSetId
+	if request.AmfSetId != "" {
+		L.Debug("Start CheckAmfSetId:", request.AmfSetId)
+		b, err = CheckAmfSetId(request.AmfSetId)
+		if err != nil {
+			b = false
+			L.Error("CheckAmfSetId failed:", err)
+			return b, err
+		}
+		L.Debug("CheckAmfSetId success.")
+	}
+	// check PreferredTai
+	if request.preferredTai != nil {
+		L.Debug("Start CheckPreferredTai:", *request.preferredTai)
+		b, err = CheckTai(*request.preferredTai)
+		if err != nil {
+			b = false
+			L.Error("CheckPreferredTai failed:", err)
+			return b, err
+		}
+		L.Debug("CheckPreferredTai success.")
+	}
+	// check PreferredNFInstances
+	L.Debug("Start CheckPreferredNFInstances:", request.PreferredNFInstances)
+	for _, v := range request.PreferredNFInstances {
+		b, err = CheckNFInstanceId(v)
+		if err != nil {
+			b = false
+			L.Error("CheckPreferredNFInstances failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckPreferredNFInstances success.")
+	// check PreferredApiVersions
+	L.Debug("Start CheckPreferredApiVersions:", request.preferredApiVersions)
+	for k, v := range request.preferredApiVersions {
+		b, err = CheckServiceName(k)
+		if err == nil {
+			b, err = CheckApiVersion(v)
+		}
+		if err != nil {
+			b = false
+			L.Error("CheckPreferredApiVersions failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckPreferredApiVersions success.")
+	// check MaxNFInstances and MaxPayloadSize
+	L.Debug("Start CheckMaxNFInstances:", request.MaxNFInstances)
+	if request.MaxNFInstances < 0 {
+		b, err = false, errors.New("MaxNFInstances is invalid")
+		L.Error("CheckMaxNFInstances failed:", err)
+		return b, err
+	}
+	L.Debug("CheckMaxNFInstances success.")
+	L.Debug("Start CheckMaxPayloadSize:", request.MaxPayloadSize)
+	if request.MaxPayloadSize < 0 || request.MaxPayloadSize > maxPayloadSize {
+		b, err = false, errors.New("MaxPayloadSize is invalid")
+		L.Error("CheckMaxPayloadSize failed:", err)
+		return b, err
+	}
+	L.Debug("CheckMaxPayloadSize success.")
+	// check TargetNfSetId
+	if request.TargetNfSetId != "" {
+		L.Debug("Start CheckTargetNfSetId:", request.TargetNfSetId)
+		b, err = CheckNfSetId(request.TargetNfSetId)
+		if err != nil {
+			b = false
+			L.Error("CheckTargetNfSetId failed:", err)
+			return b, err
+		}
+		L.Debug("CheckTargetNfSetId success.")
+	}
+	// check TargetNfServiceSetId
+	if request.TargetNfServiceSetId != "" {
+		L.Debug("Start CheckTargetNfServiceSetId:", request.TargetNfServiceSetId)
+		b, err = CheckNfServiceSetId(request.TargetNfServiceSetId)
+		if err != nil {
+			b = false
+			L.Error("CheckTargetNfServiceSetId failed:", err)
+			return b, err
+		}
+		L.Debug("CheckTargetNfServiceSetId success.")
+	}
+	// check RequesterFeatures
+	L.Debug("Start CheckRequesterFeatures:", request.RequesterFeatures)
+	b, err = CheckSupportedFeatures(request.RequesterFeatures)
+	if err != nil {
+		b = false
+		L.Error("CheckRequesterFeatures failed:", err)
+		return b, err
+	}
+	L.Debug("CheckRequesterFeatures success.")
+	return b, err
+}
+
+func handleNFDiscoverQuery(request *NFDiscoverRequest) (err error) {
+	err = nil
+	// handle ServiceNames, NsiList and GroupIdList, array query parameters are comma separated
+	request.ServiceNames = splitQueryList(request.ServiceNames)
+	request.NsiList = splitQueryList(request.NsiList)
+	request.GroupIdList = splitQueryList(request.GroupIdList)
+	request.PreferredNFInstances = splitQueryList(request.PreferredNFInstances)
+	L.Debug("HandleServiceNames success:", request.ServiceNames)
+	// handle NFInstanceIds
+	_ = HandleNFInstanceId(&request.RequesterNFInstanceId)
+	_ = HandleNFInstanceId(&request.TargetNFInstanceId)
+	for i := range request.PreferredNFInstances {
+		_ = HandleNFInstanceId(&request.PreferredNFInstances[i])
+	}
+	L.Debug("HandleNFInstanceIds success.")
+	// handle JSON encoded query parameters
+	for _, v := range []struct {
+		name  string
+		query string
+		value interface{}
+	}{
+		{"snssais", request.SNssais, &request.snssais},
+		{"requester-snssais", request.RequesterSNssais, &request.requesterSnssais},
+		{"target-plmn-list", request.TargetPlmnList, &request.targetPlmnList},
+		{"requester-plmn-list", request.RequesterPlmnList, &request.requesterPlmnList},
+		{"tai", request.Tai, &request.tai},
+		{"guami", request.Guami, &request.guami},
+		{"preferred-tai", request.PreferredTai, &request.preferredTai},
+		{"preferred-api-versions", request.PreferredApiVersions, &request.preferredApiVersions},
+	} {
+		if v.query == "" {
+			continue
+		}
+		L.Debug("Start HandleJSONQuery:", v.name, v.query)
+		err = json.Unmarshal([]byte(v.query), v.value)
+		if err != nil {
+			L.Error("HandleJSONQuery failed:", v.name, err)
+			return fmt.Errorf("query parameter %s is invalid: %w", v.name, err)
+		}
+	}
+	L.Debug("HandleJSONQuery success.")
+	// identify requester by query parameters
+	request.requester = nfRequester{
+		NFType:       request.RequesterNFType,
+		NFInstanceId: request.RequesterNFInstanceId,
+		Fqdn:         request.RequesterNFInstanceFqdn,
+		PlmnList:     request.requesterPlmnList,
+		SNssais:      request.requesterSnssais,
+	}
+	// handle CanaryRelease, opted in or within the configured share of requesters
+	request.canary = request.CanaryRelease || inCanaryReleaseShare(request.RequesterNFInstanceId, NRFConfigure.CanaryReleaseShare)
+	L.Debug("HandleCanaryRelease success:", request.canary)
+	return err
+}
+
+func inCanaryReleaseShare(requesterNFInstanceId string, share int) bool {
+	if share <= 0 {
+		return false
+	}
+	// the same requester instance always falls in or out of the share
+	if requesterNFInstanceId != "" {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(requesterNFInstanceId))
+		return int(hash.Sum32()%100) < share
+	}
+	return rand.Intn(100) < share
+}
+
+func splitQueryList(values []string) (list []string) {
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+	}
+	return list
+}
+
+func formPageLink(context *gin.Context, pageNumber int) (link *Link) {
+	// keep the request query and only replace page-number
+	query := context.Request.URL.Query()
+	query.Set("page-number", strconv.Itoa(pageNumber))
+	return &Link{Href: fmt.Sprintf("%s://%s%s?%s", autodetectHttpProtocol(context), autodetectHttpHost(context), context.Request.URL.Path, query.Encode())}
+}
+
+func handleAccessTokenForm(request *AccessTokenReq) (err error) {
+	err = nil
+	// handle NFInstanceIds
+	_ = HandleNFInstanceId(&request.NFInstanceId)
+	_ = HandleNFInstanceId(&request.TargetNFInstanceId)
+	L.Debug("HandleNFInstanceIds success.")
+	// handle Scope, service names are space separated
+	request.scopes = strings.Fields(request.Scope)
+	L.Debug("HandleScope success:", request.scopes)
+	// handle JSON encoded form parameters
+	for _, v := range []struct {
+		name  string
+		form  string
+		value interface{}
+	}{
+		{"requesterPlmn", request.RequesterPlmn, &request.requesterPlmn},
+		{"targetPlmn", request.TargetPlmn, &request.targetPlmn},
+		{"requesterSnssaiList", request.RequesterSnssaiList, &request.requesterSnssaiList},
+	} {
+		if v.form == "" {
+			continue
+		}
+		L.Debug("Start HandleJSONForm:", v.name, v.form)
+		err = json.Unmarshal([]byte(v.form), v.value)
+		if err != nil {
+			L.Error("HandleJSONForm failed:", v.name, err)
+			return fmt.Errorf("form parameter %s is invalid: %w", v.name, err)
+		}
+	}
+	L.Debug("HandleJSONForm success.")
+	return err
+}
+
+func checkAccessTokenIEs(request *AccessTokenReq) (b bool, err error) {
+	b, err = true, nil
+	// check mandatory IEs...
+	// check NFInstanceId
+	L.Debug("Start CheckNFInstanceId:", request.NFInstanceId)
+	b, err = CheckNFInstanceId(request.NFInstanceId)
+	if err != nil {
+		b = false
+		L.Error("CheckNFInstanceId failed:", err)
+		return b, err
+	}
+	L.Debug("CheckNFInstanceId success.")
+	// check Scope
+	L.Debug("Start CheckScope:", request.scopes)
+	if len(request.scopes) == 0 {
+		b, err = false, errors.New("scope is empty")
+		L.Error("CheckScope failed:", err)
+		return b, err
+	}
+	for _, v := range request.scopes {
+		b, err = CheckServiceName(v)
+		if err != nil {
+			b = false
+			L.Error("CheckScope failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckScope success.")
+	// check conditional IEs...
+	// check TargetNFType and TargetNFInstanceId, one of them identifies the audience
+	if request.TargetNFType == "" && request.TargetNFInstanceId == "" {
+		b, err = false, errors.New("targetNfType or targetNfInstanceId is required")
+		L.Error("CheckTarget failed:", err)
+		return b, err
+	}
+	// check NFType
+	if request.NFType != "" {
+		L.Debug("Start CheckNFType:", request.NFType)
+		b, err = CheckNFType(request.NFType)
+		if err != nil {
+			b = false
+			L.Error("CheckNFType failed:", err)
+			return b, err
+		}
+		L.Debug("CheckNFType success.")
+	}
+	// check TargetNFType
+	if request.TargetNFType != "" {
+		L.Debug("Start CheckTargetNFType:", request.TargetNFType)
+		b, err = CheckNFType(request.TargetNFType)
+		if err != nil {
+			b = false
+			L.Error("CheckTargetNFType failed:", err)
+			return b, err
+		}
+		L.Debug("CheckTargetNFType success.")
+	}
+	// check TargetNFInstanceId
+	if request.TargetNFInstanceId != "" {
+		L.Debug("Start CheckTargetNFInstanceId:", request.TargetNFInstanceId)
+		b, err = CheckNFInstanceId(request.TargetNFInstanceId)
+		if err != nil {
+			b = false
+			L.Error("CheckTargetNFInstanceId failed:", err)
+			return b, err
+		}
+		L.Debug("CheckTargetNFInstanceId success.")
+	}
+	// check RequesterPlmn and TargetPlmn
+	for _, v := range []*PlmnId{request.requesterPlmn, request.targetPlmn} {
+		if v == nil {
+			continue
+		}
+		L.Debug("Start CheckPlmnId:", *v)
+		b, err = CheckPlmnId(*v)
+		if err != nil {
+			b = false
+			L.Error("CheckPlmnId failed:", err)
+			return b, err
+		}
+		L.Debug("CheckPlmnId success.")
+	}
+	// check RequesterSnssaiList
+	L.Debug("Start CheckRequesterSnssaiList:", request.requesterSnssaiList)
+	for _, v := range request.requesterSnssaiList {
+		b, err = CheckSnssai(v)
+		if err != nil {
+			b = false
+			L.Error("CheckRequesterSnssaiList failed:", err)
+			return b, err
+		}
+	}
+	L.Debug("CheckRequesterSnssaiList success.")
+	// check ClientAssertionType and ClientAssertion, both or none present
+	if request.ClientAssertionType != "" || request.ClientAssertion != "" {
+		L.Debug("Start CheckClientAssertionType:", request.ClientAssertionType)
+		if request.ClientAssertionType != jwtBearerAssertionType || request.ClientAssertion == "" {
+			b, err = false, errors.New("client_assertion_type or client_assertion is invalid")
+			L.Error("CheckClientAssertionType failed:", err)
+			return b, err
+		}
+		L.Debug("CheckClientAssertionType success.")
+	}
+	return b, err
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"crypto/tls"
+	"github.com/gin-gonic/gin"
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/util"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// nfRequester identifies the consumer of NFManagement and NFDiscover operations,
+// a requester without PLMNs or S-NSSAIs is denied profiles restricted to some;
+// only a verified requester, identified by its access token or TLS client
+// certificate, owns its profile, others are identified by what they claim
+type nfRequester struct {
+	NFType       string
+	NFInstanceId string
+	Fqdn         string
+	PlmnList     []PlmnId
+	SNssais      []Snssai
+	verified     bool
+}
+
+// compiled allowedNfDomains patterns shared by all requests
+var nfDomainPatterns sync.Map
+
+func (nrf *NRF) identifyRequester(context *gin.Context) (requester nfRequester) {
+	requester, verified := nrf.verifiedRequester(context)
+	if verified {
+		return requester
+	}
+	// User-Agent of NF consumers is "<NFType>-<NFInstanceId> <FQDN>", as of TS 29.500,
+	// it is not authenticated so allowed* restrictions are advisory to such requesters
+	fields := strings.Fields(context.GetHeader("User-Agent"))
+	if len(fields) == 0 {
+		return requester
+	}
+	nfType, nfInstanceId, found := strings.Cut(fields[0], "-")
+	if !found {
+		return requester
+	}
+	if b, _ := CheckNFType(nfType); !b {
+		return requester
+	}
+	requester.NFType = nfType
+	if b, _ := CheckNFInstanceId(nfInstanceId); b {
+		requester.NFInstanceId = strings.ToLower(nfInstanceId)
+	}
+	if len(fields) > 1 {
+		requester.Fqdn = fields[1]
+	}
+	// complete requester from its registered profile, caller holds nrf.mutex
+	for _, v := range nrf.instances[requester.NFType] {
+		if requester.NFInstanceId != "" && v.NFInstanceId == requester.NFInstanceId {
+			if requester.Fqdn == "" {
+				requester.Fqdn = v.Fqdn
+			}
+			requester.PlmnList = v.PlmnList
+			requester.SNssais = v.SNssais
+			break
+		}
+	}
+	return requester
+}
+
+func (nrf *NRF) verifiedRequester(context *gin.Context) (requester nfRequester, verified bool) {
+	// subject of the verified access token and its registered profile, caller holds nrf.mutex
+	if NRFConfigure.OAuth2Settings.AuthorizationRequired {
+		return nrf.registeredRequester(context.GetString("clientID"), true), true
+	}
+	// NF instance of the TLS client certificate verified by mutual TLS
+	nfInstanceId := certificateNFInstanceId(context.Request.TLS)
+	if nfInstanceId != "" {
+		return nrf.registeredRequester(nfInstanceId, true), true
+	}
+	return requester, false
+}
+
+func certificateNFInstanceId(state *tls.ConnectionState) (nfInstanceId string) {
+	// SAN URI urn:uuid:<nfInstanceId> of the verified TLS client certificate, as of TS 33.310
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	for _, v := range state.PeerCertificates[0].URIs {
+		if !strings.EqualFold(v.Scheme, "urn") {
+			continue
+		}
+		nfInstanceId, found := strings.CutPrefix(strings.ToLower(v.Opaque), "uuid:")
+		if b, _ := CheckNFInstanceId(nfInstanceId); found && b {
+			return nfInstanceId
+		}
+	}
+	return ""
+}
+
+func (nrf *NRF) registeredRequester(nfInstanceId string, verified bool) (requester nfRequester) {
+	// caller holds nrf.mutex, unregistered requesters have no attributes to be authorized by
+	requester.NFInstanceId = nfInstanceId
+	requester.verified = verified && nfInstanceId != ""
+	profile := nrf.findNFProfile(nfInstanceId)
+	if nfInstanceId == "" || profile == nil {
+		return requester
+	}
+	requester.NFType = profile.NFType
+	requester.Fqdn = profile.Fqdn
+	requester.PlmnList = profile.PlmnList
+	requester.SNssais = profile.SNssais
+	return requester
+}
+
+func authorizeNFProfile(requester *nfRequester, profile *NFProfile) bool {
+	// NF can always access its own profile
+	if requester.verified && requester.NFInstanceId == profile.NFInstanceId {
+		return true
+	}
+	return authorizeRequester(requester, profile.AllowedNfTypes, profile.AllowedPlmns, profile.AllowedNssais, profile.AllowedNfDomains)
+}
+
+func authorizeNFService(requester *nfRequester, service *NFService) bool {
+	return authorizeRequester(requester, service.AllowedNfTypes, service.AllowedPlmns, service.AllowedNssais, service.AllowedNfDomains)
+}
+
+func authorizeRequester(requester *nfRequester, allowedNfTypes []string, allowedPlmns []PlmnId, allowedNssais []Snssai, allowedNfDomains []string) bool {
+	if len(allowedNfTypes) != 0 && !containsString(allowedNfTypes, requester.NFType) {
+		return false
+	}
+	if len(allowedPlmns) != 0 && !intersectPlmnIds(requester.PlmnList, allowedPlmns) {
+		return false
+	}
+	if len(allowedNssais) != 0 && !intersectSnssais(requester.SNssais, allowedNssais) {
+		return false
+	}
+	if len(allowedNfDomains) != 0 && !matchNfDomains(allowedNfDomains, requester.Fqdn) {
+		return false
+	}
+	return true
+}
+
+func matchNfDomains(allowedNfDomains []string, fqdn string) bool {
+	if fqdn == "" {
+		return false
+	}
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	for _, v := range allowedNfDomains {
+		pattern, exists := nfDomainPatterns.Load(v)
+		if !exists {
+			// pattern shall match the whole FQDN
+			compiled, err := regexp.Compile("^(?:" + v + ")$")
+			if err != nil {
+				continue
+			}
+			pattern, _ = nfDomainPatterns.LoadOrStore(v, compiled)
+		}
+		if pattern.(*regexp.Regexp).MatchString(fqdn) {
+			return true
+		}
+	}
+	return false
+}
+
+func filterNFServices(requester *nfRequester, profile *NFProfile) {
+	// hide services the requester is not allowed to access
+	if requester.verified && requester.NFInstanceId == profile.NFInstanceId {
+		return
+	}
+	var services []NFService
+	for _, v := range profile.NFServices {
+		if authorizeNFService(requester, &v) {
+			services = append(services, v)
+		}
+	}
+	profile.NFServices = services
+}
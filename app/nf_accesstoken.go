@@ -1,80 +1,344 @@
 package app
 
 import (
-	"crypto/rand"
-	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/golang-jwt/jwt/v5"
 	"net/http"
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/logs"
+	"strings"
 	"time"
 )
 
-type OAuthConfig struct {
-	ClientID     string
-	ClientSecret string
-	PrivateKey   *rsa.PrivateKey
-	PublicKey    *rsa.PublicKey
-}
-
 type ProtectedResource struct {
 	NFInstanceID string `json:"nfInstanceId"`
 	IPAddress    string `json:"ipAddress"`
 }
 
-var oauthConfig = &OAuthConfig{
-	ClientID:     "NRF_Service",
-	ClientSecret: "123456",
-	PrivateKey:   generateRSAKey(),
-	PublicKey:    nil,
+// NRF services protected by access tokens
+var nrfServiceNames = []string{"nnrf-nfm", "nnrf-disc"}
+
+// access token expiry when not configured, in seconds
+const defaultAccessTokenValidityTime = 3600
+
+// AccessTokenReq is posted as application/x-www-form-urlencoded, complex IEs are JSON encoded
+type AccessTokenReq struct {
+	GrantType           string `form:"grant_type" binding:"required"`
+	NFInstanceId        string `form:"nfInstanceId" binding:"required"`
+	NFType              string `form:"nfType" binding:"omitempty"`
+	TargetNFType        string `form:"targetNfType" binding:"omitempty"`
+	Scope               string `form:"scope" binding:"required"`
+	TargetNFInstanceId  string `form:"targetNfInstanceId" binding:"omitempty"`
+	RequesterPlmn       string `form:"requesterPlmn" binding:"omitempty"`
+	TargetPlmn          string `form:"targetPlmn" binding:"omitempty"`
+	RequesterSnssaiList string `form:"requesterSnssaiList" binding:"omitempty"`
+	// private_key_jwt client authentication
+	ClientAssertionType string `form:"client_assertion_type" binding:"omitempty"`
+	ClientAssertion     string `form:"client_assertion" binding:"omitempty"`
+	// JSON encoded form parameters decoded by handleAccessTokenForm
+	requesterPlmn       *PlmnId
+	targetPlmn          *PlmnId
+	requesterSnssaiList []Snssai
+	// space separated service names of scope
+	scopes []string
 }
 
-func generateRSAKey() *rsa.PrivateKey {
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		panic("failed to generate RSA key")
-	}
-	return privateKey
+// AccessTokenClaims are the claims of granted access tokens, as of TS 29.510
+type AccessTokenClaims struct {
+	jwt.RegisteredClaims
+	Scope          string  `json:"scope"`
+	ConsumerPlmnId *PlmnId `json:"consumerPlmnId,omitempty"`
+	ProducerPlmnId *PlmnId `json:"producerPlmnId,omitempty"`
 }
 
-func HandleAccessToken(context *gin.Context) {
-	clientID := context.PostForm("client_id")
-	clientSecret := context.PostForm("client_secret")
-	grantType := context.PostForm("grant_type")
-	// verify client credentials
-	if clientID != oauthConfig.ClientID || clientSecret != oauthConfig.ClientSecret {
-		context.JSON(http.StatusUnauthorized, gin.H{
-			"error": "invalid_client",
-		})
+func (nrf *NRF) HandleAccessToken(context *gin.Context) {
+	var request AccessTokenReq
+	// record context in logs
+	L.Info("AccessToken request:", context.Request)
+	// access token responses shall not be cached
+	context.Header("Cache-Control", "no-store")
+	context.Header("Pragma", "no-cache")
+	// check request form parameters
+	L.Debug("Start bind AccessToken request form.")
+	err := context.ShouldBindWith(&request, binding.FormPost)
+	if err != nil {
+		var accessTokenErr AccessTokenErr
+		accessTokenErr.Error = "invalid_request"
+		accessTokenErr.ErrorDescription = err.Error()
+		context.JSON(http.StatusBadRequest, accessTokenErr)
+		L.Error("AccessToken request form bind failed:", err)
+		return
+	}
+	L.Debug("AccessToken request form bind success.")
+	// only client credentials grant is supported
+	if request.GrantType != "client_credentials" {
+		var accessTokenErr AccessTokenErr
+		accessTokenErr.Error = "unsupported_grant_type"
+		accessTokenErr.ErrorDescription = fmt.Sprintf("grant_type %s is not supported", request.GrantType)
+		context.JSON(http.StatusBadRequest, accessTokenErr)
+		L.Error("AccessToken request grant type unsupported:", request.GrantType)
+		return
+	}
+	// handle form parameters
+	err = handleAccessTokenForm(&request)
+	if err != nil {
+		var accessTokenErr AccessTokenErr
+		accessTokenErr.Error = "invalid_request"
+		accessTokenErr.ErrorDescription = err.Error()
+		context.JSON(http.StatusBadRequest, accessTokenErr)
+		L.Error("AccessToken request form handle failed:", err)
 		return
 	}
-	// verify grant_type
-	if grantType != "client_credentials" {
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": "unsupported_grant_type",
-		})
+	// check form parameters
+	b, err := checkAccessTokenIEs(&request)
+	if b == false && err != nil {
+		var accessTokenErr AccessTokenErr
+		accessTokenErr.Error = "invalid_request"
+		accessTokenErr.ErrorDescription = err.Error()
+		context.JSON(http.StatusBadRequest, accessTokenErr)
+		L.Error("AccessToken request check failed:", err)
 		return
 	}
-	// creat JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
-		"iss": "nrf-oauth-server",
-		"sub": clientID,
-		"aud": []string{"nrf-service"},
-		"exp": time.Now().Add(time.Hour * 1).Unix(),
-		"iat": time.Now().Unix(),
-	})
-	// convert token to string
-	tokenString, err := token.SignedString(oauthConfig.PrivateKey)
+	// authenticate requester by its client credentials assertion
+	if request.ClientAssertion != "" || NRFConfigure.OAuth2Settings.ClientAssertionRequired {
+		b, err = nrf.checkClientAssertion(&request, time.Now())
+		if b == false && err != nil {
+			var accessTokenErr AccessTokenErr
+			accessTokenErr.Error = "invalid_client"
+			accessTokenErr.ErrorDescription = err.Error()
+			context.JSON(http.StatusUnauthorized, accessTokenErr)
+			L.Error("AccessToken request client assertion check failed:", err)
+			return
+		}
+		L.Debug("AccessToken request client assertion check success.")
+	} else {
+		// otherwise authenticate requester by its mutual TLS client certificate
+		b, err = checkClientCertificate(context.Request.TLS, request.NFInstanceId)
+		if b == false && err != nil {
+			var accessTokenErr AccessTokenErr
+			accessTokenErr.Error = "invalid_client"
+			accessTokenErr.ErrorDescription = err.Error()
+			context.JSON(http.StatusUnauthorized, accessTokenErr)
+			L.Error("AccessToken request client certificate check failed:", err)
+			return
+		}
+		L.Debug("AccessToken request client certificate check success.")
+	}
+	// authorize requester against NRF Service database
+	claims, errorCode, err := func(request *AccessTokenReq) (claims AccessTokenClaims, errorCode string, err error) {
+		nrf.mutex.RLock()
+		defer nrf.mutex.RUnlock()
+		return nrf.grantAccessToken(request)
+	}(&request)
+	if err != nil {
+		var accessTokenErr AccessTokenErr
+		accessTokenErr.Error = errorCode
+		accessTokenErr.ErrorDescription = err.Error()
+		status := http.StatusBadRequest
+		if errorCode == "invalid_client" {
+			status = http.StatusUnauthorized
+		}
+		context.JSON(status, accessTokenErr)
+		L.Error("AccessToken request authorize failed:", errorCode, err)
+		return
+	}
+	// sign access token by the active key, identified by kid
+	tokenString, err := func(claims AccessTokenClaims) (tokenString string, err error) {
+		key, err := nrf.signingKeys.signingKey(time.Now())
+		if err != nil {
+			return "", err
+		}
+		token := jwt.NewWithClaims(key.method, claims)
+		token.Header["kid"] = key.kid
+		return token.SignedString(key.signer)
+	}(claims)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed_to_generate_token",
-		})
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Internal Server Error"
+		problemDetails.Status = http.StatusInternalServerError
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusInternalServerError, problemDetails)
+		L.Error("AccessToken request sign failed:", err)
 		return
 	}
-	// return access token
-	context.JSON(http.StatusOK, gin.H{
-		"access_token": tokenString,
-		"token_type":   "Bearer",
-		"expires_in":   3600,
-	})
+	// return success response
+	response := AccessTokenRsp{
+		AccessToken: tokenString,
+		TokenType:   "Bearer",
+		ExpiresIn:   accessTokenValidityTime(),
+		Scope:       claims.Scope,
+	}
+	context.Header("Content-Type", "application/json")
+	context.JSON(http.StatusOK, response)
+	L.Info("AccessToken granted:", claims.Subject, claims.Audience, claims.Scope)
+	return
+}
+
+func (nrf *NRF) grantAccessToken(request *AccessTokenReq) (claims AccessTokenClaims, errorCode string, err error) {
+	// caller holds nrf.mutex, requester shall be registered with the claimed NF type
+	requester := nrf.findNFProfile(request.NFInstanceId)
+	if requester == nil {
+		// authenticated NFs not registered yet are granted NFManagement only, to register themselves
+		targetNRF := request.TargetNFType == "NRF" || (request.TargetNFInstanceId != "" && request.TargetNFInstanceId == NRFConfigure.NRFInstanceId)
+		if !targetNRF || len(request.scopes) != 1 || request.scopes[0] != "nnrf-nfm" {
+			return claims, "invalid_client", errors.New("nfInstanceId is not registered")
+		}
+		if request.TargetNFType != "" && request.TargetNFType != "NRF" {
+			return claims, "invalid_request", errors.New("targetNfType does not match the target NF instance")
+		}
+		audience := "NRF"
+		if request.TargetNFInstanceId != "" {
+			audience = request.TargetNFInstanceId
+		}
+		return formAccessTokenClaims(request, audience), "", nil
+	}
+	if request.NFType != "" && request.NFType != requester.NFType {
+		return claims, "invalid_client", errors.New("nfType does not match the registered profile")
+	}
+	// requester PLMN and S-NSSAIs shall be among the registered ones
+	if request.requesterPlmn != nil && len(requester.PlmnList) != 0 && !containsPlmnId(requester.PlmnList, *request.requesterPlmn) {
+		return claims, "unauthorized_client", errors.New("requesterPlmn is not served by the requester")
+	}
+	for _, v := range request.requesterSnssaiList {
+		if !containsSnssai(requester.SNssais, v) {
+			return claims, "unauthorized_client", errors.New("requesterSnssaiList is not served by the requester")
+		}
+	}
+	// collect services the requester may access on the target NF type or instance
+	targetNFType := request.TargetNFType
+	if request.TargetNFInstanceId != "" && request.TargetNFInstanceId == NRFConfigure.NRFInstanceId {
+		// NRF itself is not registered
+		if targetNFType != "" && targetNFType != "NRF" {
+			return claims, "invalid_request", errors.New("targetNfType does not match the target NF instance")
+		}
+		targetNFType = "NRF"
+	} else if request.TargetNFInstanceId != "" {
+		target := nrf.findNFProfile(request.TargetNFInstanceId)
+		if target == nil {
+			return claims, "invalid_request", errors.New("targetNfInstanceId is not registered")
+		}
+		if targetNFType != "" && targetNFType != target.NFType {
+			return claims, "invalid_request", errors.New("targetNfType does not match the target NF instance")
+		}
+		targetNFType = target.NFType
+	}
+	// requester is authenticated by its client assertion or client certificate
+	consumer := nfRequester{
+		NFType:       requester.NFType,
+		NFInstanceId: requester.NFInstanceId,
+		Fqdn:         requester.Fqdn,
+		PlmnList:     requester.PlmnList,
+		SNssais:      requester.SNssais,
+		verified:     true,
+	}
+	exposed := make(map[string]bool)
+	if targetNFType == "NRF" {
+		for _, v := range nrfServiceNames {
+			exposed[v] = true
+		}
+	}
+	for _, v := range nrf.instances[targetNFType] {
+		if request.TargetNFInstanceId != "" && v.NFInstanceId != request.TargetNFInstanceId {
+			continue
+		}
+		if !authorizeNFProfile(&consumer, &v.NFProfile) {
+			continue
+		}
+		for _, j := range v.NFServices {
+			if authorizeNFService(&consumer, &j) {
+				exposed[j.ServiceName] = true
+			}
+		}
+	}
+	// every requested scope shall be exposed
+	for _, v := range request.scopes {
+		if !exposed[v] {
+			return claims, "invalid_scope", fmt.Errorf("scope %s is not exposed by %s", v, targetNFType)
+		}
+	}
+	// audience is the target NF instance, or the target NF type
+	audience := targetNFType
+	if request.TargetNFInstanceId != "" {
+		audience = request.TargetNFInstanceId
+	}
+	return formAccessTokenClaims(request, audience), "", nil
+}
+
+func formAccessTokenClaims(request *AccessTokenReq, audience string) (claims AccessTokenClaims) {
+	// subject is the authenticated requester
+	now := time.Now()
+	return AccessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    NRFConfigure.NRFInstanceId,
+			Subject:   request.NFInstanceId,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(accessTokenValidityTime()) * time.Second)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Scope:          strings.Join(request.scopes, " "),
+		ConsumerPlmnId: request.requesterPlmn,
+		ProducerPlmnId: request.targetPlmn,
+	}
+}
+
+func accessTokenValidityTime() int {
+	if NRFConfigure.AccessTokenValidityTime <= 0 {
+		return defaultAccessTokenValidityTime
+	}
+	return NRFConfigure.AccessTokenValidityTime
+}
+
+func checkClientCertificate(state *tls.ConnectionState, nfInstanceId string) (b bool, err error) {
+	b, err = true, nil
+	L.Debug("Start CheckClientCertificate:", nfInstanceId)
+	// mutual TLS verified the chain of the client certificate against caFile
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return false, errors.New("client_assertion or a verified TLS client certificate is required")
+	}
+	if !matchCertificateNFInstanceId(state.PeerCertificates[0], nfInstanceId) {
+		return false, errors.New("TLS client certificate does not identify nfInstanceId")
+	}
+	L.Debug("CheckClientCertificate success.")
+	return b, err
+}
+
+func matchCertificateNFInstanceId(certificate *x509.Certificate, nfInstanceId string) bool {
+	// NF certificates identify the NF instance by SAN URI urn:uuid:<nfInstanceId>, as of TS 33.310
+	for _, v := range certificate.URIs {
+		if strings.EqualFold(v.Scheme, "urn") && strings.EqualFold(v.Opaque, "uuid:"+nfInstanceId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (nrf *NRF) findNFProfile(nfInstanceId string) (profile *NFProfile) {
+	// caller holds nrf.mutex
+	for _, v := range nrf.instances {
+		for i := range v {
+			if v[i].NFInstanceId == nfInstanceId {
+				return &v[i].NFProfile
+			}
+		}
+	}
+	return nil
+}
+
+func (nrf *NRF) HandleJWKSRetrieve(context *gin.Context) {
+	// record context in logs
+	L.Info("JWKSRetrieve request:", context.Request)
+	// publish verification keys of access tokens
+	response := nrf.signingKeys.jwks(time.Now())
+	// return success response
+	context.Header("Content-Type", "application/json")
+	context.Header("ETag", formETag(response))
+	context.JSON(http.StatusOK, response)
 	return
 }
@@ -0,0 +1,638 @@
+package app
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	. "nrf/conf"
+	. "nrf/data"
+	"strings"
+	"testing"
+	"time"
+)
+
+func requestTestAccessToken(t testing.TB, router *gin.Engine, form url.Values, certificate *x509.Certificate) (w *httptest.ResponseRecorder) {
+	// http request AccessToken
+	w = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// client certificate verified by mutual TLS
+	if certificate != nil {
+		request.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{certificate},
+			VerifiedChains:   [][]*x509.Certificate{{certificate}},
+		}
+	}
+	router.ServeHTTP(w, request)
+	return w
+}
+
+func createTestCertificate(t testing.TB, commonName string, nfInstanceId string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (certificate *x509.Certificate, key *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	// NF certificates identify the NF instance by SAN URI
+	if nfInstanceId != "" {
+		template.URIs = []*url.URL{{Scheme: "urn", Opaque: "uuid:" + nfInstanceId}}
+	}
+	// self-signed CA without parent
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+	certificate, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error parsing certificate: %v", err)
+	}
+	return certificate, key
+}
+
+func retrieveTestJWKS(t testing.TB, router *gin.Engine) jwt.Keyfunc {
+	// http request JWKSRetrieve
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/oauth2/jwks", nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var jwks JsonWebKeySet
+	err = json.Unmarshal(w.Body.Bytes(), &jwks)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	// verify access tokens by the RSA key of their kid
+	return func(token *jwt.Token) (interface{}, error) {
+		for _, v := range jwks.Keys {
+			if v.Kid == token.Header["kid"] && v.Kty == "RSA" {
+				n, _ := base64.RawURLEncoding.DecodeString(v.N)
+				e, _ := base64.RawURLEncoding.DecodeString(v.E)
+				return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+			}
+		}
+		return nil, errors.New("kid not published")
+	}
+}
+
+func TestHandleAccessToken(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleAccessToken
+	// Test Purpose: Test HandleAccessToken grants access tokens to registered NF consumers
+	// Test Steps:
+	// 1. register an AMF consumer and an SMF producer
+	// 2. send AccessTokenReq for an SMF service by targetNfType and by targetNfInstanceId
+	// 3. receive 200 OK with AccessTokenRsp and verify the access token by JWKS and its claims
+	// 4. send AccessTokenReq without client certificate or with a forged nfInstanceId and receive 401
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	plmnId := PlmnId{Mcc: "460", Mnc: "00"}
+	amf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		PlmnList:     []PlmnId{plmnId},
+		SNssais:      []Snssai{{Sst: 1, Sd: "000001"}},
+	}
+	smf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		NFServices:   []NFService{testNFService("0", "nsmf-pdusession"), testNFService("1", "nsmf-event-exposure")},
+	}
+	registerTestNFProfile(t, router, amf)
+	registerTestNFProfile(t, router, smf)
+	amfCertificate, _ := createTestCertificate(t, "amf.5gc.com", amf.NFInstanceId, nil, nil)
+	keyfunc := retrieveTestJWKS(t, router)
+	// http request AccessToken by targetNfType and targetNfInstanceId
+	for audience, form := range map[string]url.Values{
+		"SMF": {
+			"grant_type":          {"client_credentials"},
+			"nfInstanceId":        {amf.NFInstanceId},
+			"nfType":              {"AMF"},
+			"targetNfType":        {"SMF"},
+			"scope":               {"nsmf-pdusession nsmf-event-exposure"},
+			"requesterPlmn":       {`{"mcc":"460","mnc":"00"}`},
+			"requesterSnssaiList": {`[{"sst":1,"sd":"000001"}]`},
+		},
+		smf.NFInstanceId: {
+			"grant_type":         {"client_credentials"},
+			"nfInstanceId":       {amf.NFInstanceId},
+			"targetNfInstanceId": {smf.NFInstanceId},
+			"scope":              {"nsmf-pdusession"},
+		},
+	} {
+		w := requestTestAccessToken(t, router, form, amfCertificate)
+		assert.Equal(t, http.StatusOK, w.Code, audience)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		var response AccessTokenRsp
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.Equal(t, accessTokenValidityTime(), response.ExpiresIn)
+		assert.Equal(t, form.Get("scope"), response.Scope)
+		// verify access token claims
+		var claims AccessTokenClaims
+		_, err = jwt.ParseWithClaims(response.AccessToken, &claims, keyfunc)
+		assert.NoError(t, err)
+		assert.Equal(t, NRFConfigure.NRFInstanceId, claims.Issuer)
+		assert.Equal(t, amf.NFInstanceId, claims.Subject)
+		assert.Equal(t, jwt.ClaimStrings{audience}, claims.Audience)
+		assert.Equal(t, form.Get("scope"), claims.Scope)
+		assert.NotNil(t, claims.ExpiresAt)
+	}
+	// http request AccessToken without client certificate, or with a forged nfInstanceId
+	for certificate, nfInstanceId := range map[*x509.Certificate]string{
+		nil:            amf.NFInstanceId,
+		amfCertificate: smf.NFInstanceId,
+	} {
+		w := requestTestAccessToken(t, router, url.Values{
+			"grant_type":   {"client_credentials"},
+			"nfInstanceId": {nfInstanceId},
+			"targetNfType": {"SMF"},
+			"scope":        {"nsmf-pdusession"},
+		}, certificate)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, nfInstanceId)
+		var response AccessTokenErr
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		assert.Equal(t, "invalid_client", response.Error)
+	}
+}
+
+func TestHandleAccessTokenWithDefaultValidityTime(t *testing.T) {
+	// start http test service without accessTokenValidityTime configured
+	server, router := startTestServer()
+	defer server.Close()
+	defer func(validityTime int) {
+		NRFConfigure.AccessTokenValidityTime = validityTime
+	}(NRFConfigure.AccessTokenValidityTime)
+	NRFConfigure.AccessTokenValidityTime = 0
+	assert.Equal(t, defaultAccessTokenValidityTime, accessTokenValidityTime())
+	// register network functions
+	amf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+	}
+	smf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		NFServices:   []NFService{testNFService("0", "nsmf-pdusession")},
+	}
+	registerTestNFProfile(t, router, amf)
+	registerTestNFProfile(t, router, smf)
+	amfCertificate, _ := createTestCertificate(t, "amf.5gc.com", amf.NFInstanceId, nil, nil)
+	// http request AccessToken, granted token is not expired
+	w := requestTestAccessToken(t, router, url.Values{
+		"grant_type":   {"client_credentials"},
+		"nfInstanceId": {amf.NFInstanceId},
+		"targetNfType": {"SMF"},
+		"scope":        {"nsmf-pdusession"},
+	}, amfCertificate)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response AccessTokenRsp
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, defaultAccessTokenValidityTime, response.ExpiresIn)
+	var claims AccessTokenClaims
+	_, err = jwt.ParseWithClaims(response.AccessToken, &claims, retrieveTestJWKS(t, router))
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(defaultAccessTokenValidityTime*time.Second), claims.ExpiresAt.Time, time.Minute)
+	// negative validity time is not configured either
+	NRFConfigure.AccessTokenValidityTime = -1
+	assert.Equal(t, defaultAccessTokenValidityTime, accessTokenValidityTime())
+}
+
+func TestHandleAccessTokenWithInvalidRequests(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleAccessTokenWithInvalidRequests
+	// Test Purpose: Test HandleAccessToken rejects invalid or unauthorized AccessTokenReq
+	// Test Steps:
+	// 1. register an AMF consumer and an SMF producer with a restricted service
+	// 2. send invalid AccessTokenReq
+	// 3. receive AccessTokenErr with the matching error code
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	amf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		PlmnList:     []PlmnId{{Mcc: "460", Mnc: "00"}},
+	}
+	restricted := testNFService("1", "nsmf-event-exposure")
+	restricted.AllowedNfTypes = []string{"PCF"}
+	smf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		NFServices:   []NFService{testNFService("0", "nsmf-pdusession"), restricted},
+	}
+	registerTestNFProfile(t, router, amf)
+	registerTestNFProfile(t, router, smf)
+	amfCertificate, _ := createTestCertificate(t, "amf.5gc.com", amf.NFInstanceId, nil, nil)
+	// http request AccessToken with invalid form parameters
+	for _, v := range []struct {
+		form      map[string]string
+		status    int
+		errorCode string
+	}{
+		{map[string]string{"grant_type": "password"}, http.StatusBadRequest, "unsupported_grant_type"},
+		{map[string]string{"scope": ""}, http.StatusBadRequest, "invalid_request"},
+		{map[string]string{"scope": "nsmf_pdusession"}, http.StatusBadRequest, "invalid_request"},
+		{map[string]string{"targetNfType": ""}, http.StatusBadRequest, "invalid_request"},
+		{map[string]string{"requesterPlmn": "460-00"}, http.StatusBadRequest, "invalid_request"},
+		{map[string]string{"targetNfInstanceId": uuid.New().String()}, http.StatusBadRequest, "invalid_request"},
+		{map[string]string{"nfInstanceId": uuid.New().String()}, http.StatusUnauthorized, "invalid_client"},
+		{map[string]string{"nfType": "PCF"}, http.StatusUnauthorized, "invalid_client"},
+		{map[string]string{"requesterPlmn": `{"mcc":"001","mnc":"01"}`}, http.StatusBadRequest, "unauthorized_client"},
+		{map[string]string{"requesterSnssaiList": `[{"sst":1}]`}, http.StatusBadRequest, "unauthorized_client"},
+		{map[string]string{"scope": "namf-comm"}, http.StatusBadRequest, "invalid_scope"},
+		{map[string]string{"scope": "nsmf-pdusession nsmf-event-exposure"}, http.StatusBadRequest, "invalid_scope"},
+	} {
+		form := url.Values{
+			"grant_type":   {"client_credentials"},
+			"nfInstanceId": {amf.NFInstanceId},
+			"nfType":       {"AMF"},
+			"targetNfType": {"SMF"},
+			"scope":        {"nsmf-pdusession"},
+		}
+		for key, value := range v.form {
+			form.Set(key, value)
+		}
+		w := requestTestAccessToken(t, router, form, amfCertificate)
+		assert.Equal(t, v.status, w.Code, v.form)
+		var response AccessTokenErr
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		assert.Equal(t, v.errorCode, response.Error, v.form)
+	}
+}
+
+func TestAuthorizationMiddleware(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestAuthorizationMiddleware
+	// Test Purpose: Test AuthorizationMiddleware enforces access tokens of the NRF services scope
+	// Test Steps:
+	// 1. protect routes with the authorization configuration and exemptions as shipped
+	// 2. bootstrap an AMF and an SMF by nnrf-nfm access tokens granted before registration
+	// 3. send NFManagement and NFDiscover requests without, with invalid and with valid access tokens
+	// 4. receive 401, 400 or 403 with WWW-Authenticate challenge, or success
+	-------------------------------------------------------------------------*/
+	// initialize NRF Service with protected routes
+	nrf := New()
+	err := nrf.Init()
+	if err != nil {
+		t.Fatalf("Error initializing NRF: %v", err)
+	}
+	defer func(required bool) {
+		NRFConfigure.OAuth2Settings.AuthorizationRequired = required
+	}(NRFConfigure.OAuth2Settings.AuthorizationRequired)
+	NRFConfigure.OAuth2Settings.AuthorizationRequired = true
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(nrf.AuthorizationMiddleware(authorizationConfig()))
+	router.GET("/nnrf-nfm/v1/nf-instances", nrf.HandleNFListRetrieve)
+	router.PUT("/nnrf-nfm/v1/nf-instances/:nfInstanceID", nrf.HandleNFRegisterOrNFProfileCompleteReplacement)
+	router.GET("/nnrf-nfm/v1/nf-instances/:nfInstanceID", nrf.HandleNFProfileRetrieve)
+	router.PATCH("/nnrf-nfm/v1/nf-instances/:nfInstanceID", nrf.HandleNFUpdate)
+	router.DELETE("/nnrf-nfm/v1/nf-instances/:nfInstanceID", nrf.HandleNFDeregister)
+	router.POST("/nnrf-nfm/v1/subscriptions", nrf.HandleNFStatusSubscribe)
+	router.DELETE("/nnrf-nfm/v1/subscriptions/:subscriptionID", nrf.HandleNFStatusUnsubscribe)
+	router.GET("/nnrf-disc/v1/nf-instances", nrf.HandleNFDiscover)
+	router.GET("/nnrf-disc/v1/searches/:searchId", nrf.HandleSearchRetrieve)
+	router.GET("/nnrf-disc/v1/searches/:searchId/complete", nrf.HandleSearchCompleteRetrieve)
+	router.POST("/oauth2/token", nrf.HandleAccessToken)
+	// http request with access token
+	serveTestRequest := func(method string, uri string, body interface{}, accessToken string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			if err != nil {
+				t.Errorf("Error marshalling body: %v", err)
+			}
+			reader = bytes.NewReader(data)
+		}
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(method, uri, reader)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			request.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		router.ServeHTTP(w, request)
+		return w
+	}
+	grantTestAccessToken := func(profile NFProfile, scope string, status int) string {
+		certificate, _ := createTestCertificate(t, strings.ToLower(profile.NFType)+".5gc.com", profile.NFInstanceId, nil, nil)
+		w := requestTestAccessToken(t, router, url.Values{
+			"grant_type":   {"client_credentials"},
+			"nfInstanceId": {profile.NFInstanceId},
+			"nfType":       {profile.NFType},
+			"targetNfType": {"NRF"},
+			"scope":        {scope},
+		}, certificate)
+		assert.Equal(t, status, w.Code, profile.NFType, scope)
+		var response AccessTokenRsp
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return response.AccessToken
+	}
+	// NFRegister is not exempted, NFs register by nnrf-nfm access tokens granted before registration
+	amf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+	}
+	smf := NFProfile{
+		NFInstanceId:   uuid.New().String(),
+		NFType:         "SMF",
+		NFStatus:       "REGISTERED",
+		AllowedNfTypes: []string{"AMF"},
+	}
+	assert.Equal(t, http.StatusUnauthorized, serveTestRequest(http.MethodPut, "/nnrf-nfm/v1/nf-instances/"+amf.NFInstanceId, amf, "").Code)
+	grantTestAccessToken(amf, "nnrf-disc", http.StatusUnauthorized)
+	amfToken := grantTestAccessToken(amf, "nnrf-nfm", http.StatusOK)
+	smfToken := grantTestAccessToken(smf, "nnrf-nfm", http.StatusOK)
+	assert.Equal(t, http.StatusCreated, serveTestRequest(http.MethodPut, "/nnrf-nfm/v1/nf-instances/"+amf.NFInstanceId, amf, amfToken).Code)
+	assert.Equal(t, http.StatusCreated, serveTestRequest(http.MethodPut, "/nnrf-nfm/v1/nf-instances/"+smf.NFInstanceId, smf, smfToken).Code)
+	// access tokens only modify the NF instance of their subject
+	hijacked := smf
+	hijacked.AllowedNfTypes = []string{"AMF", "PCF"}
+	patch := []PatchItem{{Op: "replace", Path: "/nfStatus", Value: "SUSPENDED"}}
+	for _, v := range []struct {
+		method string
+		body   interface{}
+	}{
+		{http.MethodPut, hijacked},
+		{http.MethodPatch, patch},
+		{http.MethodDelete, nil},
+	} {
+		w := serveTestRequest(v.method, "/nnrf-nfm/v1/nf-instances/"+smf.NFInstanceId, v.body, amfToken)
+		assert.Equal(t, http.StatusForbidden, w.Code, v.method)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_scope"`)
+	}
+	assert.Equal(t, http.StatusOK, serveTestRequest(http.MethodGet, "/nnrf-nfm/v1/nf-instances/"+smf.NFInstanceId, nil, amfToken).Code)
+	// requester is identified by the access token subject, which owns its profile whatever allowedNfTypes
+	assert.Equal(t, http.StatusOK, serveTestRequest(http.MethodGet, "/nnrf-nfm/v1/nf-instances/"+smf.NFInstanceId, nil, smfToken).Code)
+	subscription := SubscriptionData{NFStatusNotificationUri: "http://127.0.0.1/notify", ReqNFInstanceId: smf.NFInstanceId}
+	assert.Equal(t, http.StatusForbidden, serveTestRequest(http.MethodPost, "/nnrf-nfm/v1/subscriptions", subscription, amfToken).Code)
+	subscription.ReqNFInstanceId = ""
+	w := serveTestRequest(http.MethodPost, "/nnrf-nfm/v1/subscriptions", subscription, amfToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	_ = json.Unmarshal(w.Body.Bytes(), &subscription)
+	assert.Equal(t, amf.NFInstanceId, subscription.ReqNFInstanceId)
+	// subscriptions are cancelled by their subscriber only
+	w = serveTestRequest(http.MethodDelete, "/nnrf-nfm/v1/subscriptions/"+subscription.SubscriptionId, nil, smfToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusNoContent, serveTestRequest(http.MethodDelete, "/nnrf-nfm/v1/subscriptions/"+subscription.SubscriptionId, nil, amfToken).Code)
+	assert.Equal(t, http.StatusNotFound, serveTestRequest(http.MethodDelete, "/nnrf-nfm/v1/subscriptions/"+subscription.SubscriptionId, nil, amfToken).Code)
+	// registered NFs are granted nnrf-disc access tokens
+	discToken := grantTestAccessToken(amf, "nnrf-disc", http.StatusOK)
+	// sign access tokens with invalid claims
+	signTestAccessToken := func(claims AccessTokenClaims) string {
+		key, err := nrf.signingKeys.signingKey(time.Now())
+		assert.NoError(t, err)
+		token := jwt.NewWithClaims(key.method, claims)
+		token.Header["kid"] = key.kid
+		tokenString, err := token.SignedString(key.signer)
+		assert.NoError(t, err)
+		return tokenString
+	}
+	validClaims := func() AccessTokenClaims {
+		return AccessTokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    NRFConfigure.NRFInstanceId,
+				Subject:   amf.NFInstanceId,
+				Audience:  jwt.ClaimStrings{NRFConfigure.NRFInstanceId},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			Scope: "nnrf-nfm",
+		}
+	}
+	expired, issuer, audience := validClaims(), validClaims(), validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	issuer.Issuer = uuid.New().String()
+	audience.Audience = jwt.ClaimStrings{"SMF"}
+	// http request protected routes
+	for _, v := range []struct {
+		uri           string
+		authorization string
+		status        int
+		challenge     string
+	}{
+		{"/nnrf-disc/v1/nf-instances?target-nf-type=SMF&requester-nf-type=AMF", "", http.StatusUnauthorized, `Bearer realm="NRF"`},
+		{"/nnrf-disc/v1/nf-instances?target-nf-type=SMF&requester-nf-type=AMF", "Basic " + discToken, http.StatusBadRequest, `error="invalid_request"`},
+		{"/nnrf-disc/v1/nf-instances?target-nf-type=SMF&requester-nf-type=AMF", "Bearer invalid", http.StatusUnauthorized, `error="invalid_token"`},
+		{"/nnrf-disc/v1/nf-instances?target-nf-type=SMF&requester-nf-type=AMF", "Bearer " + discToken, http.StatusOK, ""},
+		{"/nnrf-nfm/v1/nf-instances", "Bearer " + discToken, http.StatusForbidden, `error="insufficient_scope"`},
+		{"/nnrf-nfm/v1/nf-instances", "Bearer " + signTestAccessToken(validClaims()), http.StatusOK, ""},
+		{"/nnrf-nfm/v1/nf-instances", "Bearer " + signTestAccessToken(expired), http.StatusUnauthorized, `error="invalid_token"`},
+		{"/nnrf-nfm/v1/nf-instances", "Bearer " + signTestAccessToken(issuer), http.StatusUnauthorized, `error="invalid_token"`},
+		{"/nnrf-nfm/v1/nf-instances", "Bearer " + signTestAccessToken(audience), http.StatusUnauthorized, `error="invalid_token"`},
+	} {
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, v.uri, nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		if v.authorization != "" {
+			request.Header.Set("Authorization", v.authorization)
+		}
+		router.ServeHTTP(w, request)
+		assert.Equal(t, v.status, w.Code, v.uri, v.authorization)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), v.challenge)
+		if v.status == http.StatusForbidden {
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), `scope="nnrf-nfm"`)
+		}
+	}
+	// SMF allowing AMF is discovered by the AMF of the access token, whatever requester-nf-type claims
+	w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=PCF")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/nnrf-disc/v1/nf-instances?target-nf-type=SMF&requester-nf-type=PCF", nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+discToken)
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.NFInstances, 1)
+	// stored search is retrieved by its requester only
+	smfDiscToken := grantTestAccessToken(smf, "nnrf-disc", http.StatusOK)
+	for _, uri := range []string{
+		"/nnrf-disc/v1/searches/" + response.SearchId,
+		"/nnrf-disc/v1/searches/" + response.SearchId + "/complete",
+	} {
+		assert.Equal(t, http.StatusOK, serveTestRequest(http.MethodGet, uri, nil, discToken).Code, uri)
+		w = serveTestRequest(http.MethodGet, uri, nil, smfDiscToken)
+		assert.Equal(t, http.StatusNotFound, w.Code, uri)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestHandleAccessTokenWithClientAssertion(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleAccessTokenWithClientAssertion
+	// Test Purpose: Test HandleAccessToken authenticates requesters by private_key_jwt client assertions
+	// Test Steps:
+	// 1. register an AMF and an SMF, issue their NF certificates by a client CA
+	// 2. send AccessTokenReq with valid, replayed, invalid and other NF certified client assertions
+	// 3. receive 200 OK, or AccessTokenErr invalid_client or invalid_request
+	-------------------------------------------------------------------------*/
+	// initialize NRF Service trusting the client CA
+	nrf := New()
+	err := nrf.Init()
+	if err != nil {
+		t.Fatalf("Error initializing NRF: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/nnrf-nfm/v1/nf-instances/:nfInstanceID", nrf.HandleNFRegisterOrNFProfileCompleteReplacement)
+	router.POST("/oauth2/token", nrf.HandleAccessToken)
+	// register network functions
+	amf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+	}
+	smf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		NFServices:   []NFService{testNFService("0", "nsmf-pdusession")},
+	}
+	registerTestNFProfile(t, router, amf)
+	registerTestNFProfile(t, router, smf)
+	// NF certificates identify their NF instance
+	ca, caKey := createTestCertificate(t, "ca.5gc.com", "", nil, nil)
+	amfCertificate, amfKey := createTestCertificate(t, "amf.5gc.com", amf.NFInstanceId, ca, caKey)
+	smfCertificate, smfKey := createTestCertificate(t, "smf.5gc.com", smf.NFInstanceId, ca, caKey)
+	anonymousCertificate, anonymousKey := createTestCertificate(t, "amf.5gc.com", "", ca, caKey)
+	untrustedCertificate, untrustedKey := createTestCertificate(t, "amf.5gc.com", amf.NFInstanceId, nil, nil)
+	nrf.clientCAs = x509.NewCertPool()
+	nrf.clientCAs.AddCert(ca)
+	// sign client credentials assertions with the NF certificate in x5c
+	signTestAssertion := func(claims ClientCredentialsAssertion, certificate *x509.Certificate, key *ecdsa.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["x5c"] = []string{base64.StdEncoding.EncodeToString(certificate.Raw)}
+		tokenString, err := token.SignedString(key)
+		assert.NoError(t, err)
+		return tokenString
+	}
+	validAssertion := func() ClientCredentialsAssertion {
+		return ClientCredentialsAssertion{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   amf.NFInstanceId,
+				Audience:  jwt.ClaimStrings{"NRF"},
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				ID:        uuid.New().String(),
+			},
+			NFType: "AMF",
+		}
+	}
+	replayed := signTestAssertion(validAssertion(), amfCertificate, amfKey)
+	subject, audience, expired, lifetime, jti := validAssertion(), validAssertion(), validAssertion(), validAssertion(), validAssertion()
+	subject.Subject = smf.NFInstanceId
+	audience.Audience = jwt.ClaimStrings{"SMF"}
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	lifetime.ExpiresAt = jwt.NewNumericDate(time.Now().Add(2 * time.Hour))
+	jti.ID = ""
+	// http request AccessToken with client assertions
+	for _, v := range []struct {
+		assertionType string
+		assertion     string
+		status        int
+		errorCode     string
+	}{
+		{jwtBearerAssertionType, replayed, http.StatusOK, ""},
+		{jwtBearerAssertionType, replayed, http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(validAssertion(), untrustedCertificate, untrustedKey), http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(validAssertion(), amfCertificate, untrustedKey), http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(subject, amfCertificate, amfKey), http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(validAssertion(), smfCertificate, smfKey), http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(validAssertion(), anonymousCertificate, anonymousKey), http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(audience, amfCertificate, amfKey), http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(expired, amfCertificate, amfKey), http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(lifetime, amfCertificate, amfKey), http.StatusUnauthorized, "invalid_client"},
+		{jwtBearerAssertionType, signTestAssertion(jti, amfCertificate, amfKey), http.StatusUnauthorized, "invalid_client"},
+		{"urn:ietf:params:oauth:client-assertion-type:saml2-bearer", signTestAssertion(validAssertion(), amfCertificate, amfKey), http.StatusBadRequest, "invalid_request"},
+	} {
+		w := requestTestAccessToken(t, router, url.Values{
+			"grant_type":            {"client_credentials"},
+			"nfInstanceId":          {amf.NFInstanceId},
+			"nfType":                {"AMF"},
+			"targetNfType":          {"SMF"},
+			"scope":                 {"nsmf-pdusession"},
+			"client_assertion_type": {v.assertionType},
+			"client_assertion":      {v.assertion},
+		}, nil)
+		assert.Equal(t, v.status, w.Code, v.errorCode)
+		var response AccessTokenErr
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, v.errorCode, response.Error)
+	}
+	// client assertion is mandatory when required
+	defer func(required bool) {
+		NRFConfigure.OAuth2Settings.ClientAssertionRequired = required
+	}(NRFConfigure.OAuth2Settings.ClientAssertionRequired)
+	NRFConfigure.OAuth2Settings.ClientAssertionRequired = true
+	w := requestTestAccessToken(t, router, url.Values{
+		"grant_type":   {"client_credentials"},
+		"nfInstanceId": {amf.NFInstanceId},
+		"targetNfType": {"SMF"},
+		"scope":        {"nsmf-pdusession"},
+	}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
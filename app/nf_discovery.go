@@ -1 +1,650 @@
 package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/features"
+	. "nrf/logs"
+	"strings"
+)
+
+const (
+	// max-payload-size is expressed in kilo-octets up to 2 mega-octets
+	kiloOctets     = 1000
+	maxPayloadSize = 2000
+)
+
+type NFDiscoverRequest struct {
+	TargetNFType            string   `form:"target-nf-type" binding:"required"`
+	RequesterNFType         string   `form:"requester-nf-type" binding:"required"`
+	ServiceNames            []string `form:"service-names" binding:"omitempty"`
+	RequesterNFInstanceId   string   `form:"requester-nf-instance-id" binding:"omitempty"`
+	RequesterNFInstanceFqdn string   `form:"requester-nf-instance-fqdn" binding:"omitempty"`
+	TargetNFInstanceId      string   `form:"target-nf-instance-id" binding:"omitempty"`
+	TargetNFFqdn            string   `form:"target-nf-fqdn" binding:"omitempty"`
+	TargetNfSetId           string   `form:"target-nf-set-id" binding:"omitempty"`
+	TargetNfServiceSetId    string   `form:"target-nf-service-set-id" binding:"omitempty"`
+	SNssais                 string   `form:"snssais" binding:"omitempty"`
+	RequesterSNssais        string   `form:"requester-snssais" binding:"omitempty"`
+	Dnn                     string   `form:"dnn" binding:"omitempty"`
+	TargetPlmnList          string   `form:"target-plmn-list" binding:"omitempty"`
+	RequesterPlmnList       string   `form:"requester-plmn-list" binding:"omitempty"`
+	NsiList                 []string `form:"nsi-list" binding:"omitempty"`
+	Supi                    string   `form:"supi" binding:"omitempty"`
+	Gpsi                    string   `form:"gpsi" binding:"omitempty"`
+	ExternalGroupIdentity   string   `form:"external-group-identity" binding:"omitempty"`
+	RoutingIndicator        string   `form:"routing-indicator" binding:"omitempty"`
+	GroupIdList             []string `form:"group-id-list" binding:"omitempty"`
+	Tai                     string   `form:"tai" binding:"omitempty"`
+	Guami                   string   `form:"guami" binding:"omitempty"`
+	AmfRegionId             string   `form:"amf-region-id" binding:"omitempty"`
+	AmfSetId                string   `form:"amf-set-id" binding:"omitempty"`
+	PreferredLocality       string   `form:"preferred-locality" binding:"omitempty"`
+	PreferredTai            string   `form:"preferred-tai" binding:"omitempty"`
+	PreferredNFInstances    []string `form:"preferred-nf-instances" binding:"omitempty"`
+	PreferredApiVersions    string   `form:"preferred-api-versions" binding:"omitempty"`
+	MaxNFInstances          int      `form:"max-nf-instances" binding:"omitempty"`
+	MaxPayloadSize          int      `form:"max-payload-size" binding:"omitempty"`
+	// canary-release opts the requester in to CANARY_RELEASE NFs and services
+	CanaryRelease     bool   `form:"canary-release" binding:"omitempty"`
+	RequesterFeatures string `form:"requester-features" binding:"omitempty"`
+	// JSON encoded query parameters decoded by handleNFDiscoverQuery
+	snssais           []Snssai
+	requesterSnssais  []Snssai
+	targetPlmnList    []PlmnId
+	requesterPlmnList []PlmnId
+	tai               *Tai
+	// requester identified by the requester-* query parameters
+	requester nfRequester
+	// requester is served CANARY_RELEASE NFs and services
+	canary       bool
+	guami        *Guami
+	preferredTai *Tai
+	// preferred-api-versions maps service names to API versions
+	preferredApiVersions map[string]string
+	// owners of the requested identities resolved by the identity index
+	identities       *identityIndex
+	supiOwners       map[string]bool
+	gpsiOwners       map[string]bool
+	extGroupIdOwners map[string]bool
+	taiOwners        map[string]bool
+	// serving NFInstances of preferred-tai
+	preferredTaiOwners map[string]bool
+}
+
+// nfDiscoverFilter reports whether a profile matches one discovery query parameter,
+// nfServiceFilter whether one of its services does; absent parameters always match
+type nfDiscoverFilter func(request *NFDiscoverRequest, profile *NFProfile) bool
+
+type nfServiceFilter func(request *NFDiscoverRequest, service *NFService) bool
+
+var nfDiscoverFilters = []nfDiscoverFilter{
+	matchNFStatus,
+	matchTargetNFInstanceId,
+	matchTargetNFFqdn,
+	matchServiceNames,
+	matchTargetNfSetId,
+	matchTargetNfServiceSetId,
+	matchSNssais,
+	matchRequester,
+	matchSnssaiDnnInfo,
+	matchDnnInfo,
+	matchTargetPlmnList,
+	matchNsiList,
+	matchSupi,
+	matchGpsi,
+	matchExternalGroupIdentity,
+	matchRoutingIndicator,
+	matchGroupIdList,
+	matchTai,
+	matchAmfInfo,
+}
+
+var nfServiceFilters = []nfServiceFilter{
+	matchNFServiceStatus,
+	matchServiceName,
+	matchServiceTargetNfServiceSetId,
+	matchServiceSNssais,
+	matchServiceRequester,
+}
+
+func (nrf *NRF) HandleNFDiscover(context *gin.Context) {
+	var request NFDiscoverRequest
+	// record context in logs
+	L.Info("NFDiscover request:", context.Request)
+	// check request query parameters
+	L.Debug("Start bind NFDiscover request query:", context.Request.URL.RawQuery)
+	err := context.ShouldBindQuery(&request)
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		// query parameters present but malformed are invalid
+		problemDetails.Cause = "INVALID_QUERY_PARAM"
+		if context.Query("target-nf-type") == "" || context.Query("requester-nf-type") == "" {
+			problemDetails.Cause = "MANDATORY_QUERY_PARAM_MISSING"
+		}
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFDiscover request query bind failed:", err)
+		return
+	}
+	L.Debug("NFDiscover request query bind success.")
+	// handle query parameters
+	err = handleNFDiscoverQuery(&request)
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		problemDetails.Cause = "INVALID_QUERY_PARAM"
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFDiscover request query handle failed:", err)
+		return
+	}
+	// check query parameters
+	b, err := checkNFDiscoverIEs(&request)
+	if b == false && err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		problemDetails.Cause = "INVALID_QUERY_PARAM"
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFDiscover request check failed:", err)
+		return
+	}
+	// search instances in NRF Service database, and store the result before a deregistration may evict it
+	key := formSearchKey(context)
+	response, searchId := func(request *NFDiscoverRequest) (searchResult SearchResult, searchId string) {
+		nrf.mutex.RLock()
+		defer nrf.mutex.RUnlock()
+		searchResult.NFInstances = []NFProfile{}
+		// requester-* query parameters are not trusted when access tokens or TLS client certificates identify the requester
+		if requester, verified := nrf.verifiedRequester(context); verified {
+			request.requester = requester
+		}
+		resolveIdentityOwners(request, nrf.identities)
+		for _, v := range nrf.instances[request.TargetNFType] {
+			profile, matched := matchNFDiscover(request, &v.NFProfile)
+			if matched {
+				searchResult.NFInstances = append(searchResult.NFInstances, profile)
+			}
+		}
+		// order results the way consumers select producers
+		orderNFDiscover(request, searchResult.NFInstances)
+		searchResult.ValidityPeriod = NRFConfigure.DiscoveryValidityPeriod
+		searchResult.NumNfInstComplete = len(searchResult.NFInstances)
+		// negotiate supported features with requester, unknown bits are ignored
+		if request.RequesterFeatures != "" {
+			searchResult.NrfSupportedFeatures, _ = NFDiscovery.Negotiate(request.RequesterFeatures)
+		}
+		L.Debug("NFDiscover request matched instances:", len(searchResult.NFInstances))
+		// truncate sorted result, the complete one stays in the stored search
+		complete := searchResult.NFInstances
+		searchResult.NFInstances = truncateNFDiscover(request, searchResult)
+		L.Debug("NFDiscover request returned instances:", len(searchResult.NFInstances))
+		// store search result for later retrieval by searchId
+		searchId = nrf.storeSearch(key, context.GetString("clientID"), &searchResult, complete)
+		return searchResult, searchId
+	}(&request)
+	// return success response
+	context.Header("Content-Type", "application/json")
+	if searchId != "" {
+		context.Header("Location", formLocation(context, "nnrf-disc", "v1", "searches", searchId))
+	}
+	context.Header("Cache-Control", fmt.Sprintf("max-age=%d", response.ValidityPeriod))
+	context.JSON(http.StatusOK, response)
+	return
+}
+
+func truncateNFDiscover(request *NFDiscoverRequest, searchResult SearchResult) (profiles []NFProfile) {
+	profiles = searchResult.NFInstances
+	if request.MaxNFInstances > 0 && len(profiles) > request.MaxNFInstances {
+		profiles = profiles[:request.MaxNFInstances]
+	}
+	if request.MaxPayloadSize <= 0 {
+		return profiles
+	}
+	// payload of the result envelope, with room for the searchId
+	searchResult.NFInstances = []NFProfile{}
+	searchResult.SearchId = uuid.Nil.String()
+	envelope, _ := json.Marshal(searchResult)
+	size := len(envelope)
+	for i, v := range profiles {
+		body, _ := json.Marshal(v)
+		// profiles are separated by comma
+		size += len(body)
+		if i > 0 {
+			size++
+		}
+		if size > request.MaxPayloadSize*kiloOctets {
+			return profiles[:i]
+		}
+	}
+	return profiles
+}
+
+func matchNFDiscover(request *NFDiscoverRequest, profile *NFProfile) (matched NFProfile, b bool) {
+	// match profile level query parameters
+	for _, filter := range nfDiscoverFilters {
+		if !filter(request, profile) {
+			return matched, false
+		}
+	}
+	// keep services matching service level query parameters
+	matched = *profile
+	matched.NFServices = nil
+	for _, v := range profile.NFServices {
+		if matchNFService(request, &v) {
+			matched.NFServices = append(matched.NFServices, v)
+		}
+	}
+	if len(profile.NFServices) != 0 && len(matched.NFServices) == 0 {
+		return matched, false
+	}
+	return matched, true
+}
+
+func matchNFService(request *NFDiscoverRequest, service *NFService) bool {
+	for _, filter := range nfServiceFilters {
+		if !filter(request, service) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchNFStatus(request *NFDiscoverRequest, profile *NFProfile) bool {
+	return discoverableStatus(request, profile.NFStatus)
+}
+
+func matchNFServiceStatus(request *NFDiscoverRequest, service *NFService) bool {
+	return discoverableStatus(request, service.NFServiceStatus)
+}
+
+func discoverableStatus(request *NFDiscoverRequest, status string) bool {
+	// SUSPENDED and UNDISCOVERABLE are never discovered, CANARY_RELEASE only by canary requesters
+	switch status {
+	case "SUSPENDED", "UNDISCOVERABLE":
+		return false
+	case "CANARY_RELEASE":
+		return request.canary
+	}
+	return true
+}
+
+func matchTargetNFInstanceId(request *NFDiscoverRequest, profile *NFProfile) bool {
+	return request.TargetNFInstanceId == "" || request.TargetNFInstanceId == profile.NFInstanceId
+}
+
+func matchTargetNFFqdn(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if request.TargetNFFqdn == "" {
+		return true
+	}
+	// FQDN comparison is case-insensitive and ignores the trailing root label
+	return strings.EqualFold(strings.TrimSuffix(request.TargetNFFqdn, "."), strings.TrimSuffix(profile.Fqdn, "."))
+}
+
+func matchServiceNames(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if len(request.ServiceNames) == 0 {
+		return true
+	}
+	for _, v := range profile.NFServices {
+		if matchServiceName(request, &v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchServiceName(request *NFDiscoverRequest, service *NFService) bool {
+	if len(request.ServiceNames) == 0 {
+		return true
+	}
+	for _, v := range request.ServiceNames {
+		if v == service.ServiceName {
+			return true
+		}
+	}
+	return false
+}
+
+func matchTargetNfSetId(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if request.TargetNfSetId == "" {
+		return true
+	}
+	// set identifiers are FQDN like and compared case-insensitively
+	for _, v := range profile.NfSetIdList {
+		if strings.EqualFold(v, request.TargetNfSetId) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchTargetNfServiceSetId(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if request.TargetNfServiceSetId == "" {
+		return true
+	}
+	for _, v := range profile.NFServices {
+		if matchServiceTargetNfServiceSetId(request, &v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchServiceTargetNfServiceSetId(request *NFDiscoverRequest, service *NFService) bool {
+	if request.TargetNfServiceSetId == "" {
+		return true
+	}
+	for _, v := range service.NfServiceSetIdList {
+		if strings.EqualFold(v, request.TargetNfServiceSetId) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSNssais(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if len(request.snssais) == 0 {
+		return true
+	}
+	// S-NSSAIs of the profile, per PLMN ones only for the target PLMNs
+	supported := append([]Snssai(nil), profile.SNssais...)
+	for _, v := range profile.PerPlmnSnssaiList {
+		if len(request.targetPlmnList) == 0 || containsPlmnId(request.targetPlmnList, v.PlmnId) {
+			supported = append(supported, v.SNssaiList...)
+		}
+	}
+	// NF without S-NSSAIs can serve any S-NSSAI
+	if len(profile.SNssais) == 0 && len(profile.PerPlmnSnssaiList) == 0 {
+		return true
+	}
+	return intersectSnssais(request.snssais, supported)
+}
+
+func matchRequester(request *NFDiscoverRequest, profile *NFProfile) bool {
+	// profile restrictions on requester NF type, PLMN, S-NSSAI and domain
+	return authorizeNFProfile(&request.requester, profile)
+}
+
+func matchSnssaiDnnInfo(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if len(request.snssais) == 0 && request.Dnn == "" {
+		return true
+	}
+	// collect S-NSSAI and DNN pairs of smfInfo and upfInfo
+	type snssaiDnns struct {
+		snssai Snssai
+		dnns   []string
+	}
+	var items []snssaiDnns
+	var smfInfos []SmfInfo
+	if profile.SmfInfo != nil {
+		smfInfos = append(smfInfos, *profile.SmfInfo)
+	}
+	for _, v := range profile.SmfInfoList {
+		smfInfos = append(smfInfos, v)
+	}
+	for _, info := range smfInfos {
+		for _, v := range info.SNssaiSmfInfoList {
+			item := snssaiDnns{snssai: v.SNssai}
+			for _, j := range v.DnnSmfInfoList {
+				item.dnns = append(item.dnns, j.Dnn)
+			}
+			items = append(items, item)
+		}
+	}
+	var upfInfos []UpfInfo
+	if profile.UpfInfo != nil {
+		upfInfos = append(upfInfos, *profile.UpfInfo)
+	}
+	for _, v := range profile.UpfInfoList {
+		upfInfos = append(upfInfos, v)
+	}
+	for _, info := range upfInfos {
+		for _, v := range info.SNssaiUpfInfoList {
+			item := snssaiDnns{snssai: v.SNssai}
+			for _, j := range v.DnnUpfInfoList {
+				item.dnns = append(item.dnns, j.Dnn)
+			}
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return true
+	}
+	// the DNN shall be served in one of the requested S-NSSAIs
+	for _, v := range items {
+		if len(request.snssais) != 0 && !containsSnssai(request.snssais, v.snssai) {
+			continue
+		}
+		if request.Dnn != "" && !containsString(v.dnns, request.Dnn) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func matchDnnInfo(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if request.Dnn == "" {
+		return true
+	}
+	// collect DNN lists of pcfInfo and bsfInfo
+	var dnnLists [][]string
+	if profile.PcfInfo != nil {
+		dnnLists = append(dnnLists, profile.PcfInfo.DnnList)
+	}
+	for _, v := range profile.PcfInfoList {
+		dnnLists = append(dnnLists, v.DnnList)
+	}
+	if profile.BsfInfo != nil {
+		dnnLists = append(dnnLists, profile.BsfInfo.DnnList)
+	}
+	for _, v := range profile.BsfInfoList {
+		dnnLists = append(dnnLists, v.DnnList)
+	}
+	// absent DNN list means any DNN is served
+	for _, v := range dnnLists {
+		if len(v) == 0 || containsString(v, request.Dnn) {
+			return true
+		}
+	}
+	return len(dnnLists) == 0
+}
+
+func matchTargetPlmnList(request *NFDiscoverRequest, profile *NFProfile) bool {
+	// NF without PLMN list belongs to the PLMN of the NRF
+	if len(request.targetPlmnList) == 0 || len(profile.PlmnList) == 0 {
+		return true
+	}
+	return intersectPlmnIds(request.targetPlmnList, profile.PlmnList)
+}
+
+func matchNsiList(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if len(request.NsiList) == 0 || len(profile.NsiList) == 0 {
+		return true
+	}
+	for _, v := range request.NsiList {
+		if containsString(profile.NsiList, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveIdentityOwners(request *NFDiscoverRequest, index *identityIndex) {
+	request.identities = index
+	if request.Supi != "" {
+		request.supiOwners = index.supi.lookup(request.Supi, identityKey(request.Supi, "imsi-"))
+	}
+	if request.Gpsi != "" {
+		request.gpsiOwners = index.gpsi.lookup(request.Gpsi, identityKey(request.Gpsi, "msisdn-"))
+	}
+	if request.ExternalGroupIdentity != "" {
+		request.extGroupIdOwners = index.extGroupId.lookup(request.ExternalGroupIdentity, identityKey(request.ExternalGroupIdentity, "extgroupid-"))
+	}
+	if request.tai != nil {
+		request.taiOwners = index.lookupTai(*request.tai)
+	}
+	if request.preferredTai != nil {
+		request.preferredTaiOwners = index.lookupTai(*request.preferredTai)
+	}
+}
+
+func matchSupi(request *NFDiscoverRequest, profile *NFProfile) bool {
+	// NF without SUPI ranges can serve any SUPI
+	if request.Supi == "" || !request.identities.supi.owners[profile.NFInstanceId] {
+		return true
+	}
+	return request.supiOwners[profile.NFInstanceId]
+}
+
+func matchGpsi(request *NFDiscoverRequest, profile *NFProfile) bool {
+	// NF without GPSI ranges can serve any GPSI
+	if request.Gpsi == "" || !request.identities.gpsi.owners[profile.NFInstanceId] {
+		return true
+	}
+	return request.gpsiOwners[profile.NFInstanceId]
+}
+
+func matchExternalGroupIdentity(request *NFDiscoverRequest, profile *NFProfile) bool {
+	// NF without external group identifier ranges can serve any group
+	if request.ExternalGroupIdentity == "" || !request.identities.extGroupId.owners[profile.NFInstanceId] {
+		return true
+	}
+	return request.extGroupIdOwners[profile.NFInstanceId]
+}
+
+func matchRoutingIndicator(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if request.RoutingIndicator == "" {
+		return true
+	}
+	// NF without routing indicators can serve any routing indicator
+	routingIndicators := collectNFIdentities(profile).routingIndicators
+	return len(routingIndicators) == 0 || containsString(routingIndicators, request.RoutingIndicator)
+}
+
+func matchGroupIdList(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if len(request.GroupIdList) == 0 {
+		return true
+	}
+	for _, v := range collectNFIdentities(profile).groupIds {
+		if v != "" && containsString(request.GroupIdList, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchTai(request *NFDiscoverRequest, profile *NFProfile) bool {
+	// NF without tracking areas can serve any TAI
+	if request.tai == nil || !request.identities.taiOwners[profile.NFInstanceId] {
+		return true
+	}
+	return request.taiOwners[profile.NFInstanceId]
+}
+
+func matchAmfInfo(request *NFDiscoverRequest, profile *NFProfile) bool {
+	if request.guami == nil && request.AmfRegionId == "" && request.AmfSetId == "" {
+		return true
+	}
+	var amfInfos []AmfInfo
+	if profile.AmfInfo != nil {
+		amfInfos = append(amfInfos, *profile.AmfInfo)
+	}
+	for _, v := range profile.AmfInfoList {
+		amfInfos = append(amfInfos, v)
+	}
+	// region, set and GUAMI shall be served by the same amfInfo, a GUAMI is served
+	// by its own AMF and by the AMFs backing it up on failure or removal
+	for _, v := range amfInfos {
+		if request.AmfRegionId != "" && !strings.EqualFold(request.AmfRegionId, v.AmfRegionId) {
+			continue
+		}
+		if request.AmfSetId != "" && !strings.EqualFold(request.AmfSetId, v.AmfSetId) {
+			continue
+		}
+		if request.guami != nil && !containsGuami(v.GuamiList, *request.guami) && !containsGuami(v.BackupInfoAmfFailure, *request.guami) && !containsGuami(v.BackupInfoAmfRemoval, *request.guami) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func matchServiceSNssais(request *NFDiscoverRequest, service *NFService) bool {
+	if len(request.snssais) == 0 || len(service.SNssais) == 0 {
+		return true
+	}
+	return intersectSnssais(request.snssais, service.SNssais)
+}
+
+func matchServiceRequester(request *NFDiscoverRequest, service *NFService) bool {
+	// service restrictions on requester NF type, PLMN, S-NSSAI and domain
+	return authorizeNFService(&request.requester, service)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSnssai(list []Snssai, snssai Snssai) bool {
+	for _, v := range list {
+		if v.Sst == snssai.Sst && strings.EqualFold(v.Sd, snssai.Sd) {
+			return true
+		}
+	}
+	return false
+}
+
+func intersectSnssais(a []Snssai, b []Snssai) bool {
+	for _, v := range a {
+		if containsSnssai(b, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPlmnId(list []PlmnId, plmnId PlmnId) bool {
+	for _, v := range list {
+		if v == plmnId {
+			return true
+		}
+	}
+	return false
+}
+
+func containsGuami(list []Guami, guami Guami) bool {
+	for _, v := range list {
+		if v.PlmnId == guami.PlmnId && strings.EqualFold(v.AmfId, guami.AmfId) {
+			return true
+		}
+	}
+	return false
+}
+
+func intersectPlmnIds(a []PlmnId, b []PlmnId) bool {
+	for _, v := range a {
+		if containsPlmnId(b, v) {
+			return true
+		}
+	}
+	return false
+}
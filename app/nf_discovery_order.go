@@ -0,0 +1,92 @@
+package app
+
+import (
+	"math"
+	"math/rand"
+	. "nrf/data"
+	"sort"
+)
+
+// orderNFDiscover sorts matched profiles and their services in selection order:
+// preferred ones first, then ascending priority, then a capacity weighted random
+// order inside each priority band
+func orderNFDiscover(request *NFDiscoverRequest, profiles []NFProfile) {
+	// preferences in precedence order, each one outranks all the following
+	preferences := make(map[string]int, len(profiles))
+	keys := make(map[string]float64, len(profiles))
+	for _, v := range profiles {
+		for _, preferred := range []bool{
+			request.PreferredLocality != "" && v.Locality == request.PreferredLocality,
+			containsString(request.PreferredNFInstances, v.NFInstanceId),
+			request.preferredTaiOwners[v.NFInstanceId],
+			matchPreferredApiVersions(request, v.NFServices),
+		} {
+			preferences[v.NFInstanceId] <<= 1
+			if preferred {
+				preferences[v.NFInstanceId]++
+			}
+		}
+		keys[v.NFInstanceId] = weightedRandomKey(v.Capacity)
+	}
+	sort.SliceStable(profiles, func(i, j int) bool {
+		a, b := &profiles[i], &profiles[j]
+		if preferences[a.NFInstanceId] != preferences[b.NFInstanceId] {
+			return preferences[a.NFInstanceId] > preferences[b.NFInstanceId]
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return keys[a.NFInstanceId] > keys[b.NFInstanceId]
+	})
+	for i := range profiles {
+		orderNFServices(request, profiles[i].NFServices)
+	}
+}
+
+func orderNFServices(request *NFDiscoverRequest, services []NFService) {
+	preferences := make(map[string]bool, len(services))
+	keys := make(map[string]float64, len(services))
+	for _, v := range services {
+		preferences[v.ServiceInstanceId] = matchPreferredApiVersions(request, []NFService{v})
+		keys[v.ServiceInstanceId] = weightedRandomKey(v.Capacity)
+	}
+	sort.SliceStable(services, func(i, j int) bool {
+		a, b := &services[i], &services[j]
+		if preferences[a.ServiceInstanceId] != preferences[b.ServiceInstanceId] {
+			return preferences[a.ServiceInstanceId]
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return keys[a.ServiceInstanceId] > keys[b.ServiceInstanceId]
+	})
+}
+
+// weightedRandomKey draws u^(1/capacity), sorting keys descending gives a random
+// order where each item comes first with probability proportional to its capacity
+func weightedRandomKey(capacity int) float64 {
+	weight := float64(capacity)
+	if weight <= 0 {
+		weight = 1
+	}
+	return math.Pow(rand.Float64(), 1/weight)
+}
+
+func matchPreferredApiVersions(request *NFDiscoverRequest, services []NFService) bool {
+	if len(request.preferredApiVersions) == 0 {
+		return false
+	}
+	// a service of a preferred name shall offer the preferred version
+	for _, v := range services {
+		version, exists := request.preferredApiVersions[v.ServiceName]
+		if !exists {
+			continue
+		}
+		for _, j := range v.Versions {
+			if j.ApiVersionInUri == version || j.ApiFullVersion == version {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,948 @@
+package app
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	. "nrf/conf"
+	. "nrf/data"
+	"strings"
+	"testing"
+)
+
+func registerTestNFProfile(t testing.TB, router *gin.Engine, profile NFProfile) {
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	// http request NFRegister
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, "/nnrf-nfm/v1/nf-instances/"+profile.NFInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func discoverTestNFInstances(t testing.TB, router *gin.Engine, query string) (w *httptest.ResponseRecorder, response SearchResult) {
+	// http request NFDiscover
+	w = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/nnrf-disc/v1/nf-instances?"+query, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	return w, response
+}
+
+func testNFService(serviceInstanceId string, serviceName string) NFService {
+	return NFService{
+		ServiceInstanceId: serviceInstanceId,
+		ServiceName:       serviceName,
+		Scheme:            "https",
+		NFServiceStatus:   "REGISTERED",
+		Versions:          []NFServiceVersion{{ApiVersionInUri: "v1", ApiFullVersion: "1.0.0"}},
+	}
+}
+
+func TestHandleNFDiscover(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscover
+	// Test Purpose: Test HandleNFDiscover searches the NFManagement registry
+	// Test Steps:
+	// 1. register 2 SMF and 1 AMF network functions
+	// 2. send NFDiscover request with target-nf-type SMF
+	// 3. receive 200 OK with both SMF profiles
+	// 4. send NFDiscover request with service-names, target-nf-instance-id and target-nf-fqdn
+	// 5. receive 200 OK with the matching profile and services only
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	smf1 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		Fqdn:         "smf1.example.com",
+		NFServices: []NFService{
+			testNFService("1", "nsmf-pdusession"),
+			testNFService("2", "nsmf-event-exposure"),
+		},
+	}
+	smf2 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		Fqdn:         "smf2.example.com",
+		NFServices: []NFService{
+			testNFService("1", "nsmf-event-exposure"),
+		},
+	}
+	amf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+	}
+	for _, v := range []NFProfile{smf1, smf2, amf} {
+		registerTestNFProfile(t, router, v)
+	}
+	// http request NFDiscover by target-nf-type
+	w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, 3600, response.ValidityPeriod)
+	assert.Equal(t, "max-age=3600", w.Header().Get("Cache-Control"))
+	assert.Len(t, response.NFInstances, 2)
+	assert.Equal(t, 2, response.NumNfInstComplete)
+	assert.ElementsMatch(t, []string{smf1.NFInstanceId, smf2.NFInstanceId}, []string{response.NFInstances[0].NFInstanceId, response.NFInstances[1].NFInstanceId})
+	// http request NFDiscover by service-names
+	w, response = discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&service-names=nsmf-pdusession,nudm-sdm")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response.NFInstances, 1)
+	assert.Equal(t, smf1.NFInstanceId, response.NFInstances[0].NFInstanceId)
+	assert.Len(t, response.NFInstances[0].NFServices, 1)
+	assert.Equal(t, "nsmf-pdusession", response.NFInstances[0].NFServices[0].ServiceName)
+	// http request NFDiscover by target-nf-instance-id
+	w, response = discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&target-nf-instance-id="+smf2.NFInstanceId)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response.NFInstances, 1)
+	assert.Equal(t, smf2.NFInstanceId, response.NFInstances[0].NFInstanceId)
+	// http request NFDiscover by target-nf-fqdn
+	w, response = discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&target-nf-fqdn=SMF1.example.com.")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response.NFInstances, 1)
+	assert.Equal(t, smf1.NFInstanceId, response.NFInstances[0].NFInstanceId)
+	// http request NFDiscover without matching instances
+	w, response = discoverTestNFInstances(t, router, "target-nf-type=UDM&requester-nf-type=AMF&requester-features=FF")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, response.NFInstances)
+	assert.Equal(t, 0, response.NumNfInstComplete)
+	assert.Equal(t, "D2", response.NrfSupportedFeatures)
+}
+
+func TestHandleNFDiscoverWithInvalidQuery(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	for query, cause := range map[string]string{
+		"target-nf-type=SMF":                                                      "MANDATORY_QUERY_PARAM_MISSING",
+		"requester-nf-type=AMF":                                                   "MANDATORY_QUERY_PARAM_MISSING",
+		"requester-nf-type=AMF&max-nf-instances=x":                                "MANDATORY_QUERY_PARAM_MISSING",
+		"target-nf-type=XXX&requester-nf-type=AMF":                                "INVALID_QUERY_PARAM",
+		"target-nf-type=SMF&requester-nf-type=AMF&service-names=Nsmf_PDUSession":  "INVALID_QUERY_PARAM",
+		"target-nf-type=SMF&requester-nf-type=AMF&target-nf-instance-id=invalid":  "INVALID_QUERY_PARAM",
+		"target-nf-type=SMF&requester-nf-type=AMF&max-nf-instances=x":             "INVALID_QUERY_PARAM",
+		"target-nf-type=SMF&requester-nf-type=AMF&canary-release=x":               "INVALID_QUERY_PARAM",
+		"target-nf-type=SMF&requester-nf-type=AMF&snssais=%5B%7B%22sst%22%3A1%7D": "INVALID_QUERY_PARAM",
+	} {
+		// http request NFDiscover with invalid query
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/nnrf-disc/v1/nf-instances?"+query, nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		router.ServeHTTP(w, request)
+		var problemDetails ProblemDetails
+		err = json.Unmarshal(w.Body.Bytes(), &problemDetails)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		// assert http response
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.Equal(t, http.StatusBadRequest, problemDetails.Status)
+		assert.Equal(t, cause, problemDetails.Cause, query)
+	}
+}
+
+func TestHandleNFDiscoverWithSlice(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithSlice
+	// Test Purpose: Test HandleNFDiscover filters by S-NSSAI, DNN, PLMN and NSI
+	// Test Steps:
+	// 1. register SMFs serving different slices, DNNs and PLMNs, and one SMF serving any
+	// 2. send NFDiscover requests with snssais, dnn, target-plmn-list, nsi-list and requester-snssais
+	// 3. receive 200 OK with the SMFs serving the requested slice and DNN only
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	service := testNFService("1", "nsmf-pdusession")
+	service.SNssais = []Snssai{{Sst: 1, Sd: "000001"}}
+	smf1 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		PlmnList:     []PlmnId{{Mcc: "460", Mnc: "00"}},
+		SNssais:      []Snssai{{Sst: 1, Sd: "000001"}},
+		SmfInfo: &SmfInfo{
+			SNssaiSmfInfoList: []SnssaiSmfInfoItem{
+				{SNssai: Snssai{Sst: 1, Sd: "000001"}, DnnSmfInfoList: []DnnSmfInfoItem{{Dnn: "internet"}}},
+			},
+		},
+		NFServices: []NFService{service},
+	}
+	smf2 := NFProfile{
+		NFInstanceId:  uuid.New().String(),
+		NFType:        "SMF",
+		NFStatus:      "REGISTERED",
+		PlmnList:      []PlmnId{{Mcc: "460", Mnc: "01"}},
+		SNssais:       []Snssai{{Sst: 2}},
+		NsiList:       []string{"nsi-2"},
+		AllowedNssais: []Snssai{{Sst: 2}},
+		SmfInfo: &SmfInfo{
+			SNssaiSmfInfoList: []SnssaiSmfInfoItem{
+				{SNssai: Snssai{Sst: 2}, DnnSmfInfoList: []DnnSmfInfoItem{{Dnn: "ims"}}},
+			},
+		},
+	}
+	smf3 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+	}
+	for _, v := range []NFProfile{smf1, smf2, smf3} {
+		registerTestNFProfile(t, router, v)
+	}
+	// http request NFDiscover with slice query parameters
+	for query, expected := range map[string][]string{
+		"snssais=" + url.QueryEscape(`[{"sst":1,"sd":"000001"}]`):                   {smf1.NFInstanceId, smf3.NFInstanceId},
+		"snssais=" + url.QueryEscape(`[{"sst":1,"sd":"000001"}]`) + "&dnn=ims":      {smf3.NFInstanceId},
+		"snssais=" + url.QueryEscape(`[{"sst":1,"sd":"000001"}]`) + "&dnn=internet": {smf1.NFInstanceId, smf3.NFInstanceId},
+		"dnn=ims": {smf3.NFInstanceId},
+		"dnn=ims&requester-snssais=" + url.QueryEscape(`[{"sst":2}]`):                                                                {smf2.NFInstanceId, smf3.NFInstanceId},
+		"target-plmn-list=" + url.QueryEscape(`[{"mcc":"460","mnc":"01"}]`):                                                          {smf3.NFInstanceId},
+		"target-plmn-list=" + url.QueryEscape(`[{"mcc":"460","mnc":"01"}]`) + "&requester-snssais=" + url.QueryEscape(`[{"sst":2}]`): {smf2.NFInstanceId, smf3.NFInstanceId},
+		"nsi-list=nsi-9,nsi-10": {smf1.NFInstanceId, smf3.NFInstanceId},
+		"requester-snssais=" + url.QueryEscape(`[{"sst":1,"sd":"000001"}]`): {smf1.NFInstanceId, smf3.NFInstanceId},
+		"snssais=" + url.QueryEscape(`[{"sst":3}]`):                         {smf3.NFInstanceId},
+	} {
+		w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		var nfInstanceIds []string
+		for _, v := range response.NFInstances {
+			nfInstanceIds = append(nfInstanceIds, v.NFInstanceId)
+		}
+		assert.ElementsMatch(t, expected, nfInstanceIds, query)
+	}
+	// http request NFDiscover with invalid slice query parameters
+	for _, query := range []string{
+		"snssais=" + url.QueryEscape(`{"sst":1}`),
+		"snssais=" + url.QueryEscape(`[{"sst":300}]`),
+		"target-plmn-list=" + url.QueryEscape(`[{"mcc":"46","mnc":"00"}]`),
+	} {
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/nnrf-disc/v1/nf-instances?target-nf-type=SMF&requester-nf-type=AMF&"+query, nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandleNFDiscoverWithIdentityRanges(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithIdentityRanges
+	// Test Purpose: Test HandleNFDiscover filters by SUPI, GPSI, routing indicator and group id
+	// Test Steps:
+	// 1. register UDMs owning SUPI and GPSI ranges, patterns and routing indicators, and one UDM serving any
+	// 2. send NFDiscover requests with supi, gpsi, routing-indicator and group-id-list
+	// 3. receive 200 OK with the owning UDMs only
+	// 4. deregister one UDM and check the identity index no longer returns it
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	udm1 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "UDM",
+		NFStatus:     "REGISTERED",
+		UdmInfo: &UdmInfo{
+			GroupId:           "udm-group-1",
+			SupiRanges:        []SupiRange{{Start: "460000000000000", End: "460000999999999"}},
+			GpsiRanges:        []IdentityRange{{Start: "8613800000000", End: "8613899999999"}},
+			RoutingIndicators: []string{"0001"},
+		},
+	}
+	udm2 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "UDM",
+		NFStatus:     "REGISTERED",
+		UdmInfoList: map[string]UdmInfo{
+			"1": {
+				GroupId:    "udm-group-2",
+				SupiRanges: []SupiRange{{Start: "460001000000000", End: "460001999999999"}, {Pattern: "^nai-.+@operator\\.com$"}},
+			},
+		},
+	}
+	udm3 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "UDM",
+		NFStatus:     "REGISTERED",
+	}
+	for _, v := range []NFProfile{udm1, udm2, udm3} {
+		registerTestNFProfile(t, router, v)
+	}
+	// http request NFDiscover with identity query parameters
+	for query, expected := range map[string][]string{
+		"supi=imsi-460000123456789":                        {udm1.NFInstanceId, udm3.NFInstanceId},
+		"supi=imsi-460001123456789":                        {udm2.NFInstanceId, udm3.NFInstanceId},
+		"supi=imsi-460002123456789":                        {udm3.NFInstanceId},
+		"supi=" + url.QueryEscape("nai-user@operator.com"): {udm2.NFInstanceId, udm3.NFInstanceId},
+		"gpsi=msisdn-8613812345678":                        {udm1.NFInstanceId, udm2.NFInstanceId, udm3.NFInstanceId},
+		"gpsi=msisdn-8613912345678":                        {udm2.NFInstanceId, udm3.NFInstanceId},
+		"routing-indicator=0001":                           {udm1.NFInstanceId, udm2.NFInstanceId, udm3.NFInstanceId},
+		"routing-indicator=0002":                           {udm2.NFInstanceId, udm3.NFInstanceId},
+		"group-id-list=udm-group-2,udm-group-9":            {udm2.NFInstanceId},
+	} {
+		w, response := discoverTestNFInstances(t, router, "target-nf-type=UDM&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		var nfInstanceIds []string
+		for _, v := range response.NFInstances {
+			nfInstanceIds = append(nfInstanceIds, v.NFInstanceId)
+		}
+		assert.ElementsMatch(t, expected, nfInstanceIds, query)
+	}
+	// http request NFDiscover with invalid identity query parameters
+	for _, query := range []string{"supi=460000123456789", "gpsi=msisdn-86", "routing-indicator=00001"} {
+		w, _ := discoverTestNFInstances(t, router, "target-nf-type=UDM&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	// http request NFDeregister and NFDiscover again
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodDelete, "/nnrf-nfm/v1/nf-instances/"+udm2.NFInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w, response := discoverTestNFInstances(t, router, "target-nf-type=UDM&requester-nf-type=AMF&supi=imsi-460001123456789")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response.NFInstances, 1)
+	assert.Equal(t, udm3.NFInstanceId, response.NFInstances[0].NFInstanceId)
+}
+
+func TestHandleNFRegisterWithInvalidIdentityRanges(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	for _, udmInfo := range []UdmInfo{
+		{SupiRanges: []SupiRange{{Start: "460001999999999", End: "460001000000000"}}},
+		{GpsiRanges: []IdentityRange{{Start: "86138"}}},
+		{SupiRanges: []SupiRange{{Pattern: "^imsi-(460"}}},
+		{RoutingIndicators: []string{"12345"}},
+	} {
+		body, err := json.Marshal(NFProfile{
+			NFInstanceId: uuid.New().String(),
+			NFType:       "UDM",
+			NFStatus:     "REGISTERED",
+			UdmInfo:      &udmInfo,
+		})
+		if err != nil {
+			t.Errorf("Error marshalling profile: %v", err)
+		}
+		// http request NFRegister
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPut, "/nnrf-nfm/v1/nf-instances/"+uuid.New().String(), bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandleNFDiscoverWithTai(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithTai
+	// Test Purpose: Test HandleNFDiscover filters by TAI against taiList and taiRangeList
+	// Test Steps:
+	// 1. register AMFs serving TAIs, TAC ranges and TAC patterns, and one AMF serving any
+	// 2. send NFDiscover requests with tai
+	// 3. receive 200 OK with the AMFs serving the TAI only
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	plmnId := PlmnId{Mcc: "460", Mnc: "00"}
+	guamiList := []Guami{{PlmnId: plmnId, AmfId: "010041"}}
+	amf1 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		AmfInfo: &AmfInfo{
+			AmfSetId:    "001",
+			AmfRegionId: "01",
+			GuamiList:   guamiList,
+			TaiList:     []Tai{{PlmnId: plmnId, Tac: "000001"}},
+			TaiRangeList: []TaiRange{
+				{PlmnId: plmnId, TacRangeList: []TacRange{{Start: "000100", End: "0001FF"}}},
+			},
+		},
+	}
+	amf2 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		AmfInfo: &AmfInfo{
+			AmfSetId:    "002",
+			AmfRegionId: "01",
+			GuamiList:   guamiList,
+			TaiRangeList: []TaiRange{
+				{PlmnId: plmnId, TacRangeList: []TacRange{{Start: "000180", End: "0002ff"}, {Pattern: "^00A[0-9A-F]{3}$"}}},
+			},
+		},
+	}
+	amf3 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		AmfInfo: &AmfInfo{
+			AmfSetId:    "003",
+			AmfRegionId: "01",
+			GuamiList:   guamiList,
+		},
+	}
+	for _, v := range []NFProfile{amf1, amf2, amf3} {
+		registerTestNFProfile(t, router, v)
+	}
+	// http request NFDiscover with tai query parameter
+	for tai, expected := range map[string][]string{
+		`{"plmnId":{"mcc":"460","mnc":"00"},"tac":"000001"}`: {amf1.NFInstanceId, amf3.NFInstanceId},
+		`{"plmnId":{"mcc":"460","mnc":"00"},"tac":"000120"}`: {amf1.NFInstanceId, amf3.NFInstanceId},
+		`{"plmnId":{"mcc":"460","mnc":"00"},"tac":"0001a0"}`: {amf1.NFInstanceId, amf2.NFInstanceId, amf3.NFInstanceId},
+		`{"plmnId":{"mcc":"460","mnc":"00"},"tac":"0002FF"}`: {amf2.NFInstanceId, amf3.NFInstanceId},
+		`{"plmnId":{"mcc":"460","mnc":"00"},"tac":"00A001"}`: {amf2.NFInstanceId, amf3.NFInstanceId},
+		`{"plmnId":{"mcc":"460","mnc":"01"},"tac":"000001"}`: {amf3.NFInstanceId},
+	} {
+		w, response := discoverTestNFInstances(t, router, "target-nf-type=AMF&requester-nf-type=SMF&tai="+url.QueryEscape(tai))
+		assert.Equal(t, http.StatusOK, w.Code, tai)
+		var nfInstanceIds []string
+		for _, v := range response.NFInstances {
+			nfInstanceIds = append(nfInstanceIds, v.NFInstanceId)
+		}
+		assert.ElementsMatch(t, expected, nfInstanceIds, tai)
+	}
+	// http request NFDiscover with invalid tai query parameter
+	for _, tai := range []string{`{"plmnId":{"mcc":"460","mnc":"00"},"tac":"01"}`, `[{"tac":"000001"}]`} {
+		w, _ := discoverTestNFInstances(t, router, "target-nf-type=AMF&requester-nf-type=SMF&tai="+url.QueryEscape(tai))
+		assert.Equal(t, http.StatusBadRequest, w.Code, tai)
+	}
+}
+
+func TestHandleNFDiscoverWithGuami(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithGuami
+	// Test Purpose: Test HandleNFDiscover filters AMFs by guami, amf-region-id and amf-set-id
+	// Test Steps:
+	// 1. register AMFs in different regions and sets, one backing up the GUAMI of another on failure
+	// 2. send NFDiscover requests with guami, amf-region-id and amf-set-id
+	// 3. receive 200 OK with the matching AMFs only
+	// 4. deregister the serving AMF and discover the backup AMF by the failed GUAMI
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	plmnId := PlmnId{Mcc: "460", Mnc: "00"}
+	guami1 := Guami{PlmnId: plmnId, AmfId: "010041"}
+	guami2 := Guami{PlmnId: plmnId, AmfId: "010042"}
+	amf1 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		AmfInfo:      &AmfInfo{AmfSetId: "001", AmfRegionId: "01", GuamiList: []Guami{guami1}},
+	}
+	amf2 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		AmfInfo:      &AmfInfo{AmfSetId: "001", AmfRegionId: "01", GuamiList: []Guami{guami2}, BackupInfoAmfFailure: []Guami{guami1}},
+	}
+	amf3 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		AmfInfo:      &AmfInfo{AmfSetId: "001", AmfRegionId: "02", GuamiList: []Guami{{PlmnId: plmnId, AmfId: "020041"}}},
+	}
+	for _, v := range []NFProfile{amf1, amf2, amf3} {
+		registerTestNFProfile(t, router, v)
+	}
+	// http request NFDiscover with AMF query parameters
+	for query, expected := range map[string][]string{
+		"guami=" + url.QueryEscape(`{"plmnId":{"mcc":"460","mnc":"00"},"amfId":"010041"}`): {amf1.NFInstanceId, amf2.NFInstanceId},
+		"guami=" + url.QueryEscape(`{"plmnId":{"mcc":"460","mnc":"00"},"amfId":"010042"}`): {amf2.NFInstanceId},
+		"amf-region-id=01&amf-set-id=001":                                                  {amf1.NFInstanceId, amf2.NFInstanceId},
+		"amf-set-id=001":                                                                   {amf1.NFInstanceId, amf2.NFInstanceId, amf3.NFInstanceId},
+		"amf-region-id=02":                                                                 {amf3.NFInstanceId},
+		"amf-region-id=03":                                                                 nil,
+	} {
+		w, response := discoverTestNFInstances(t, router, "target-nf-type=AMF&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		var nfInstanceIds []string
+		for _, v := range response.NFInstances {
+			nfInstanceIds = append(nfInstanceIds, v.NFInstanceId)
+		}
+		assert.ElementsMatch(t, expected, nfInstanceIds, query)
+	}
+	// http request NFDiscover with invalid AMF query parameters
+	for _, query := range []string{
+		"guami=" + url.QueryEscape(`{"plmnId":{"mcc":"460","mnc":"00"},"amfId":"41"}`),
+		"amf-set-id=400",
+		"amf-region-id=1",
+	} {
+		w, _ := discoverTestNFInstances(t, router, "target-nf-type=AMF&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	// http request NFDeregister the serving AMF and NFDiscover the backup AMF
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodDelete, "/nnrf-nfm/v1/nf-instances/"+amf1.NFInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w, response := discoverTestNFInstances(t, router, "target-nf-type=AMF&requester-nf-type=AMF&guami="+url.QueryEscape(`{"plmnId":{"mcc":"460","mnc":"00"},"amfId":"010041"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response.NFInstances, 1)
+	assert.Equal(t, amf2.NFInstanceId, response.NFInstances[0].NFInstanceId)
+	assert.Equal(t, []Guami{guami1}, response.NFInstances[0].AmfInfo.BackupInfoAmfFailure)
+}
+
+func TestHandleNFDiscoverWithOrdering(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithOrdering
+	// Test Purpose: Test HandleNFDiscover orders results by preference, priority and capacity
+	// Test Steps:
+	// 1. register SMFs with different localities, priorities, capacities, TAIs and API versions
+	// 2. send NFDiscover requests with and without preferred-* query parameters
+	// 3. receive 200 OK with profiles and services in selection order
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	plmnId := PlmnId{Mcc: "460", Mnc: "00"}
+	service1 := testNFService("1", "nsmf-pdusession")
+	service1.Priority = 2
+	service2 := testNFService("2", "nsmf-pdusession")
+	service2.Priority = 1
+	service2.Versions = []NFServiceVersion{{ApiVersionInUri: "v2", ApiFullVersion: "2.0.0"}}
+	smf1 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		Priority:     1,
+		Locality:     "east",
+		NFServices:   []NFService{service1, service2},
+	}
+	smf2 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		Priority:     2,
+		Locality:     "west",
+		SmfInfo: &SmfInfo{
+			SNssaiSmfInfoList: []SnssaiSmfInfoItem{{SNssai: Snssai{Sst: 1}, DnnSmfInfoList: []DnnSmfInfoItem{{Dnn: "internet"}}}},
+			TaiList:           []Tai{{PlmnId: plmnId, Tac: "000001"}},
+		},
+	}
+	smf3 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		Priority:     3,
+		Capacity:     9900,
+		Locality:     "west",
+	}
+	smf4 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		Priority:     3,
+		Capacity:     100,
+		Locality:     "west",
+	}
+	for _, v := range []NFProfile{smf4, smf3, smf2, smf1} {
+		registerTestNFProfile(t, router, v)
+	}
+	// http request NFDiscover with preferences in precedence order, profiles of the
+	// same preference and priority are ordered at random weighted by capacity
+	for query, expected := range map[string][][]string{
+		"":                        {{smf1.NFInstanceId}, {smf2.NFInstanceId}, {smf3.NFInstanceId, smf4.NFInstanceId}},
+		"preferred-locality=west": {{smf2.NFInstanceId}, {smf3.NFInstanceId, smf4.NFInstanceId}, {smf1.NFInstanceId}},
+		"preferred-nf-instances=" + smf3.NFInstanceId + "," + smf4.NFInstanceId:                  {{smf3.NFInstanceId, smf4.NFInstanceId}, {smf1.NFInstanceId}, {smf2.NFInstanceId}},
+		"preferred-tai=" + url.QueryEscape(`{"plmnId":{"mcc":"460","mnc":"00"},"tac":"000001"}`): {{smf2.NFInstanceId}, {smf1.NFInstanceId}, {smf3.NFInstanceId, smf4.NFInstanceId}},
+		"preferred-locality=west&preferred-nf-instances=" + smf1.NFInstanceId:                    {{smf2.NFInstanceId}, {smf3.NFInstanceId, smf4.NFInstanceId}, {smf1.NFInstanceId}},
+	} {
+		w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		assert.Len(t, response.NFInstances, 4, query)
+		var nfInstanceIds []string
+		for _, v := range response.NFInstances {
+			nfInstanceIds = append(nfInstanceIds, v.NFInstanceId)
+		}
+		for _, band := range expected {
+			if len(nfInstanceIds) < len(band) {
+				break
+			}
+			assert.ElementsMatch(t, band, nfInstanceIds[:len(band)], query)
+			nfInstanceIds = nfInstanceIds[len(band):]
+		}
+	}
+	// services are ordered by priority, or by preferred API versions first
+	w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", response.NFInstances[0].NFServices[0].ServiceInstanceId)
+	w, response = discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&preferred-api-versions="+url.QueryEscape(`{"nsmf-pdusession":"v1"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, smf1.NFInstanceId, response.NFInstances[0].NFInstanceId)
+	assert.Equal(t, "1", response.NFInstances[0].NFServices[0].ServiceInstanceId)
+	// capacity weights the order inside a priority band
+	var first int
+	for i := 0; i < 100; i++ {
+		w, response = discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF")
+		assert.Equal(t, http.StatusOK, w.Code)
+		if response.NFInstances[2].NFInstanceId == smf3.NFInstanceId {
+			first++
+		}
+	}
+	assert.Greater(t, first, 80)
+	// http request NFDiscover with invalid preferences
+	for _, query := range []string{
+		"preferred-nf-instances=smf1",
+		"preferred-api-versions=" + url.QueryEscape(`{"nsmf-pdusession":"1.0"}`),
+		"preferred-tai=" + url.QueryEscape(`{"tac":"000001"}`),
+	} {
+		w, _ = discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandleNFDiscoverWithTruncation(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithTruncation
+	// Test Purpose: Test HandleNFDiscover honors max-nf-instances and max-payload-size
+	// Test Steps:
+	// 1. register 20 UPF network functions
+	// 2. send NFDiscover requests with max-nf-instances and max-payload-size
+	// 3. receive 200 OK with the truncated result and numNfInstComplete of all matches
+	// 4. retrieve the complete result through the stored search
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	for i := 0; i < 20; i++ {
+		registerTestNFProfile(t, router, NFProfile{
+			NFInstanceId: uuid.New().String(),
+			NFType:       "UPF",
+			NFStatus:     "REGISTERED",
+			Fqdn:         "upf.example.com",
+		})
+	}
+	// http request NFDiscover with max-nf-instances
+	w, response := discoverTestNFInstances(t, router, "target-nf-type=UPF&requester-nf-type=SMF&max-nf-instances=3")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response.NFInstances, 3)
+	assert.Equal(t, 20, response.NumNfInstComplete)
+	// http request SearchCompleteRetrieve
+	w = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/nnrf-disc/v1/searches/"+response.SearchId+"/complete", nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var complete StoredSearchResult
+	err = json.Unmarshal(w.Body.Bytes(), &complete)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Len(t, complete.NFInstances, 20)
+	assert.Equal(t, response.NFInstances, complete.NFInstances[:3])
+	// http request NFDiscover with max-payload-size
+	w, response = discoverTestNFInstances(t, router, "target-nf-type=UPF&requester-nf-type=SMF&max-payload-size=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.LessOrEqual(t, w.Body.Len(), 1000)
+	assert.NotEmpty(t, response.NFInstances)
+	assert.Less(t, len(response.NFInstances), 20)
+	assert.Equal(t, 20, response.NumNfInstComplete)
+	// http request NFDiscover with invalid limits
+	for _, query := range []string{"max-nf-instances=-1", "max-payload-size=2001", "max-nf-instances=many"} {
+		w, _ = discoverTestNFInstances(t, router, "target-nf-type=UPF&requester-nf-type=SMF&"+query)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandleNFDiscoverWithAllowedRequesters(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithAllowedRequesters
+	// Test Purpose: Test HandleNFDiscover hides profiles and services from requesters not allowed
+	// Test Steps:
+	// 1. register SMFs restricted by allowedNfTypes, allowedPlmns and allowedNfDomains
+	// 2. send NFDiscover requests from different requesters
+	// 3. receive 200 OK with the profiles and services the requester may access only,
+	//    requesters without PLMNs are denied PLMN restricted profiles and domains match the whole FQDN
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	service := testNFService("1", "nsmf-pdusession")
+	service.AllowedNfTypes = []string{"AMF"}
+	smf1 := NFProfile{
+		NFInstanceId:   uuid.New().String(),
+		NFType:         "SMF",
+		NFStatus:       "REGISTERED",
+		AllowedNfTypes: []string{"AMF"},
+	}
+	smf2 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		AllowedPlmns: []PlmnId{{Mcc: "460", Mnc: "00"}},
+		NFServices:   []NFService{service, testNFService("2", "nsmf-event-exposure")},
+	}
+	smf3 := NFProfile{
+		NFInstanceId:     uuid.New().String(),
+		NFType:           "SMF",
+		NFStatus:         "REGISTERED",
+		AllowedNfDomains: []string{`.*\.home\.net`},
+	}
+	for _, v := range []NFProfile{smf1, smf2, smf3} {
+		registerTestNFProfile(t, router, v)
+	}
+	// http request NFDiscover from different requesters
+	for query, expected := range map[string][]string{
+		"requester-nf-type=AMF": {smf1.NFInstanceId},
+		"requester-nf-type=PCF": {},
+		"requester-nf-type=AMF&requester-plmn-list=" + url.QueryEscape(`[{"mcc":"460","mnc":"00"}]`):                                             {smf1.NFInstanceId, smf2.NFInstanceId},
+		"requester-nf-type=AMF&requester-plmn-list=" + url.QueryEscape(`[{"mcc":"310","mnc":"410"}]`):                                            {smf1.NFInstanceId},
+		"requester-nf-type=PCF&requester-nf-instance-fqdn=pcf1.home.net":                                                                         {smf3.NFInstanceId},
+		"requester-nf-type=PCF&requester-nf-instance-fqdn=pcf1.home.net.example.org":                                                             {},
+		"requester-nf-type=PCF&requester-nf-instance-fqdn=pcf1.visited.net&requester-plmn-list=" + url.QueryEscape(`[{"mcc":"460","mnc":"00"}]`): {smf2.NFInstanceId},
+	} {
+		w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&"+query)
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		var nfInstanceIds []string
+		for _, v := range response.NFInstances {
+			nfInstanceIds = append(nfInstanceIds, v.NFInstanceId)
+			// services restricted to AMF are hidden from other requesters
+			if v.NFInstanceId == smf2.NFInstanceId && strings.Contains(query, "PCF") {
+				assert.Len(t, v.NFServices, 1, query)
+				assert.Equal(t, "nsmf-event-exposure", v.NFServices[0].ServiceName, query)
+			}
+		}
+		assert.ElementsMatch(t, expected, nfInstanceIds, query)
+	}
+	// requester of the verified TLS client certificate is not identified by requester-* query parameters
+	pcf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "PCF",
+		NFStatus:     "REGISTERED",
+		Fqdn:         "pcf1.visited.net",
+		PlmnList:     []PlmnId{{Mcc: "460", Mnc: "00"}},
+	}
+	registerTestNFProfile(t, router, pcf)
+	certificate, _ := createTestCertificate(t, "pcf.5gc.com", pcf.NFInstanceId, nil, nil)
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/nnrf-disc/v1/nf-instances?target-nf-type=SMF&requester-nf-type=AMF&requester-nf-instance-fqdn=pcf1.home.net", nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{certificate},
+		VerifiedChains:   [][]*x509.Certificate{{certificate}},
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response SearchResult
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Len(t, response.NFInstances, 1)
+	for _, v := range response.NFInstances {
+		assert.Equal(t, smf2.NFInstanceId, v.NFInstanceId)
+		assert.Len(t, v.NFServices, 1)
+	}
+}
+
+func TestHandleNFDiscoverWithNfSet(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithNfSet
+	// Test Purpose: Test HandleNFDiscover filters by target-nf-set-id and target-nf-service-set-id
+	// Test Steps:
+	// 1. register SMFs in two NF sets, with services in NF service sets
+	// 2. send NFDiscover requests with target-nf-set-id and target-nf-service-set-id
+	// 3. receive 200 OK with the set members and services only
+	// 4. deregister one set member and discover the remaining one by the set
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	setA := "setA.smfset.5gc.mnc000.mcc460"
+	setB := "setB.smfset.5gc.mnc000.mcc460"
+	service1 := testNFService("1", "nsmf-pdusession")
+	service1.NfServiceSetIdList = []string{"set1.snnsmf-pdusession.nfi54804518-4191-46b3-955c-ac631f953ed8.5gc.mnc000.mcc460"}
+	service2 := testNFService("2", "nsmf-pdusession")
+	smf1 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		NfSetIdList:  []string{setA},
+		NFServices:   []NFService{service1, service2},
+	}
+	smf2 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+		NfSetIdList:  []string{setA, setB},
+	}
+	smf3 := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+	}
+	for _, v := range []NFProfile{smf1, smf2, smf3} {
+		registerTestNFProfile(t, router, v)
+	}
+	// http request NFDiscover with set query parameters
+	for query, expected := range map[string][]string{
+		"target-nf-set-id=" + setA:                                   {smf1.NFInstanceId, smf2.NFInstanceId},
+		"target-nf-set-id=" + strings.ToUpper(setB):                  {smf2.NFInstanceId},
+		"target-nf-set-id=setC.smfset.5gc.mnc000.mcc460":             nil,
+		"target-nf-service-set-id=" + service1.NfServiceSetIdList[0]: {smf1.NFInstanceId},
+	} {
+		w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		var nfInstanceIds []string
+		for _, v := range response.NFInstances {
+			nfInstanceIds = append(nfInstanceIds, v.NFInstanceId)
+			// only services of the requested service set are returned
+			if strings.HasPrefix(query, "target-nf-service-set-id") {
+				assert.Equal(t, []NFService{service1}, v.NFServices)
+			}
+		}
+		assert.ElementsMatch(t, expected, nfInstanceIds, query)
+	}
+	// http request NFDiscover with invalid set query parameters
+	for _, query := range []string{"target-nf-set-id=setA", "target-nf-service-set-id=" + setA} {
+		w, _ := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&"+query)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	// http request NFDeregister a set member and NFDiscover the set again
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodDelete, "/nnrf-nfm/v1/nf-instances/"+smf1.NFInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&target-nf-set-id="+setA)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response.NFInstances, 1)
+	assert.Equal(t, smf2.NFInstanceId, response.NFInstances[0].NFInstanceId)
+}
+
+func TestHandleNFDiscoverWithNFStatus(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFDiscoverWithNFStatus
+	// Test Purpose: Test HandleNFDiscover hides SUSPENDED, UNDISCOVERABLE and CANARY_RELEASE NFs and services
+	// Test Steps:
+	// 1. register SMFs and services in every NF status
+	// 2. send NFDiscover requests with and without canary-release
+	// 3. receive 200 OK with CANARY_RELEASE NFs and services for canary requesters only
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	var nfInstanceIds = make(map[string]string)
+	for _, status := range []string{"REGISTERED", "SUSPENDED", "UNDISCOVERABLE", "CANARY_RELEASE"} {
+		service := testNFService("1", "nsmf-pdusession")
+		service.NFServiceStatus = status
+		suspended := testNFService("2", "nsmf-nidd")
+		suspended.NFServiceStatus = "SUSPENDED"
+		profile := NFProfile{
+			NFInstanceId: uuid.New().String(),
+			NFType:       "SMF",
+			NFStatus:     status,
+			NFServices:   []NFService{testNFService("0", "nsmf-event-exposure"), service, suspended},
+		}
+		registerTestNFProfile(t, router, profile)
+		nfInstanceIds[status] = profile.NFInstanceId
+	}
+	// http request NFDiscover with and without canary requesters
+	for query, expected := range map[string][]string{
+		"":                     {"REGISTERED"},
+		"&canary-release=true": {"REGISTERED", "CANARY_RELEASE"},
+	} {
+		w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF"+query)
+		assert.Equal(t, http.StatusOK, w.Code, query)
+		var statuses []string
+		for _, v := range response.NFInstances {
+			statuses = append(statuses, v.NFStatus)
+			// services follow the same rules through nfServiceStatus
+			var serviceStatuses []string
+			for _, j := range v.NFServices {
+				serviceStatuses = append(serviceStatuses, j.NFServiceStatus)
+			}
+			assert.ElementsMatch(t, []string{"REGISTERED", v.NFStatus}, serviceStatuses, query)
+		}
+		assert.ElementsMatch(t, expected, statuses, query)
+	}
+	// CANARY_RELEASE is served to the configured share of requesters
+	defer func(share int) {
+		NRFConfigure.CanaryReleaseShare = share
+	}(NRFConfigure.CanaryReleaseShare)
+	NRFConfigure.CanaryReleaseShare = 100
+	w, response := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF&requester-nf-instance-id="+uuid.New().String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response.NFInstances, 2)
+	// the same requester always falls in or out of the share
+	requesterNFInstanceId := uuid.New().String()
+	assert.Equal(t, inCanaryReleaseShare(requesterNFInstanceId, 30), inCanaryReleaseShare(requesterNFInstanceId, 30))
+	assert.False(t, inCanaryReleaseShare(requesterNFInstanceId, 0))
+}
+
+func BenchmarkHandleNFDiscover(b *testing.B) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	for i := 0; i < 100; i++ {
+		registerTestNFProfile(b, router, NFProfile{
+			NFInstanceId: uuid.New().String(),
+			NFType:       "SMF",
+			NFStatus:     "REGISTERED",
+			NFServices:   []NFService{testNFService("1", "nsmf-pdusession")},
+		})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w, response := discoverTestNFInstances(b, router, "target-nf-type=SMF&requester-nf-type=AMF&service-names=nsmf-pdusession")
+		assert.Equal(b, http.StatusOK, w.Code)
+		assert.Len(b, response.NFInstances, 100)
+	}
+}
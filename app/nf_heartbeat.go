@@ -0,0 +1,100 @@
+package app
+
+import (
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/logs"
+	"time"
+)
+
+const heartBeatSupervisorInterval = time.Second
+
+// heart-beat periods when not configured, in seconds
+const (
+	defaultHeartBeatTimer            = 60
+	defaultHeartBeatGracePeriod      = 10
+	defaultHeartBeatDeregisterPeriod = 60
+)
+
+func (nrf *NRF) StartHeartBeatSupervisor() {
+	go func() {
+		ticker := time.NewTicker(heartBeatSupervisorInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			nrf.superviseHeartBeats(now)
+			nrf.purgeSearches(now)
+			nrf.purgeAssertions(now)
+		}
+	}()
+	L.Info("The NRF heart-beat supervisor started.")
+}
+
+func (nrf *NRF) recordHeartBeat(nfInstanceId string) {
+	// caller should hold nrf.mutex
+	nrf.heartbeats[nfInstanceId] = time.Now()
+}
+
+func (nrf *NRF) superviseHeartBeats(now time.Time) {
+	var suspended, deregistered []NFProfile
+	defaultTimer, gracePeriod, deregisterPeriod := NRFConfigure.DefaultHeartBeatTimer, NRFConfigure.HeartBeatGracePeriod, NRFConfigure.HeartBeatDeregisterPeriod
+	if defaultTimer <= 0 {
+		defaultTimer = defaultHeartBeatTimer
+	}
+	if gracePeriod <= 0 {
+		gracePeriod = defaultHeartBeatGracePeriod
+	}
+	if deregisterPeriod <= 0 {
+		deregisterPeriod = defaultHeartBeatDeregisterPeriod
+	}
+	// notify subscribers after database unlocked
+	defer func() {
+		for _, v := range suspended {
+			nrf.notifyNFStatus("NF_PROFILE_CHANGED", formConfiguredLocation("nnrf-nfm", "v1", "nf-instances", v.NFInstanceId), v)
+		}
+		for _, v := range deregistered {
+			nrf.notifyNFStatus("NF_DEREGISTERED", formConfiguredLocation("nnrf-nfm", "v1", "nf-instances", v.NFInstanceId), v)
+		}
+	}()
+	nrf.mutex.Lock()
+	defer nrf.mutex.Unlock()
+	for k, v := range nrf.instances {
+		for i := 0; i < len(v); i++ {
+			instance := &v[i]
+			last, exists := nrf.heartbeats[instance.NFInstanceId]
+			if !exists {
+				// instance stored before supervision started
+				nrf.heartbeats[instance.NFInstanceId] = now
+				continue
+			}
+			heartBeatTimer := instance.HeartBeatTimer
+			if heartBeatTimer <= 0 {
+				heartBeatTimer = defaultTimer
+			}
+			suspendAt := last.Add(time.Duration(heartBeatTimer+gracePeriod) * time.Second)
+			deregisterAt := suspendAt.Add(time.Duration(deregisterPeriod) * time.Second)
+			switch {
+			case !now.Before(deregisterAt):
+				// deregister NFInstance from database
+				// instance points at the next NFInstance once spliced out
+				nfInstanceId := instance.NFInstanceId
+				L.Warning("NFInstance heart-beat lost, deregister:", nfInstanceId, "last heart-beat:", last)
+				delete(nrf.heartbeats, nfInstanceId)
+				nrf.evictSearches(nfInstanceId)
+				nrf.identities.update(nfInstanceId, nil)
+				deregistered = append(deregistered, instance.NFProfile)
+				v = append(v[:i], v[i+1:]...)
+				nrf.instances[k] = v
+				i--
+			case !now.Before(suspendAt) && instance.NFStatus != "SUSPENDED":
+				// suspend NFInstance until next heart-beat
+				L.Warning("NFInstance heart-beat expired, status", instance.NFStatus, "-> SUSPENDED:", instance.NFInstanceId)
+				instance.NFStatus = "SUSPENDED"
+				suspended = append(suspended, instance.NFProfile)
+			}
+		}
+		// remove NFType slice when all NFInstance deleted
+		if len(v) == 0 {
+			delete(nrf.instances, k)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	. "nrf/conf"
+	. "nrf/data"
+	"testing"
+	"time"
+)
+
+func TestSuperviseHeartBeats(t *testing.T) {
+	// initialize NRF Service
+	nrf := New()
+	err := nrf.Init()
+	if err != nil {
+		t.Fatal("Error initialize NRF:", err)
+	}
+	// store instance in NRF Service database
+	nfInstanceId := uuid.New().String()
+	instance := NFInstance{NFProfile: NFProfile{
+		NFInstanceId:   nfInstanceId,
+		NFType:         "AMF",
+		NFStatus:       "REGISTERED",
+		HeartBeatTimer: 10,
+	}}
+	now := time.Now()
+	nrf.instances[instance.NFType] = append(nrf.instances[instance.NFType], instance)
+	nrf.heartbeats[nfInstanceId] = now
+	gracePeriod := time.Duration(NRFConfigure.HeartBeatGracePeriod) * time.Second
+	deregisterPeriod := time.Duration(NRFConfigure.HeartBeatDeregisterPeriod) * time.Second
+	// heart-beat timer not expired
+	nrf.superviseHeartBeats(now.Add(10 * time.Second))
+	assert.Equal(t, "REGISTERED", nrf.instances["AMF"][0].NFStatus)
+	// heart-beat timer and grace period expired
+	nrf.superviseHeartBeats(now.Add(10*time.Second + gracePeriod))
+	assert.Equal(t, "SUSPENDED", nrf.instances["AMF"][0].NFStatus)
+	// deregister period expired
+	nrf.superviseHeartBeats(now.Add(10*time.Second + gracePeriod + deregisterPeriod))
+	assert.NotContains(t, nrf.instances, "AMF")
+	assert.NotContains(t, nrf.heartbeats, nfInstanceId)
+}
+
+func TestSuperviseHeartBeatsWithDefaultPeriods(t *testing.T) {
+	// initialize NRF Service without heart-beat periods configured
+	nrf := New()
+	err := nrf.Init()
+	if err != nil {
+		t.Fatal("Error initialize NRF:", err)
+	}
+	settings := NRFConfigure
+	defer func() { NRFConfigure = settings }()
+	NRFConfigure.DefaultHeartBeatTimer, NRFConfigure.HeartBeatGracePeriod, NRFConfigure.HeartBeatDeregisterPeriod = 0, 0, 0
+	// store instance without heart-beat timer in NRF Service database
+	nfInstanceId := uuid.New().String()
+	instance := NFInstance{NFProfile: NFProfile{
+		NFInstanceId: nfInstanceId,
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+	}}
+	now := time.Now()
+	nrf.instances[instance.NFType] = append(nrf.instances[instance.NFType], instance)
+	nrf.heartbeats[nfInstanceId] = now
+	// default heart-beat timer not expired
+	nrf.superviseHeartBeats(now.Add(defaultHeartBeatTimer * time.Second))
+	assert.Equal(t, "REGISTERED", nrf.instances["AMF"][0].NFStatus)
+	// default grace period expired, NF is suspended before deregistered
+	nrf.superviseHeartBeats(now.Add((defaultHeartBeatTimer + defaultHeartBeatGracePeriod) * time.Second))
+	assert.Equal(t, "SUSPENDED", nrf.instances["AMF"][0].NFStatus)
+	nrf.superviseHeartBeats(now.Add((defaultHeartBeatTimer+defaultHeartBeatGracePeriod+defaultHeartBeatDeregisterPeriod)*time.Second - time.Second))
+	assert.Equal(t, "SUSPENDED", nrf.instances["AMF"][0].NFStatus)
+	// default deregister period expired
+	nrf.superviseHeartBeats(now.Add((defaultHeartBeatTimer + defaultHeartBeatGracePeriod + defaultHeartBeatDeregisterPeriod) * time.Second))
+	assert.NotContains(t, nrf.instances, "AMF")
+	assert.NotContains(t, nrf.heartbeats, nfInstanceId)
+}
+
+func TestSuperviseHeartBeatsWithIdentities(t *testing.T) {
+	// initialize NRF Service
+	nrf := New()
+	err := nrf.Init()
+	if err != nil {
+		t.Fatal("Error initialize NRF:", err)
+	}
+	// store a dead and a live UDM serving different SUPI ranges
+	now := time.Now()
+	dead := NFInstance{NFProfile: NFProfile{
+		NFInstanceId:   uuid.New().String(),
+		NFType:         "UDM",
+		NFStatus:       "REGISTERED",
+		HeartBeatTimer: 10,
+		UdmInfo:        &UdmInfo{SupiRanges: []SupiRange{{Start: "460000000000000", End: "460009999999999"}}},
+	}}
+	live := NFInstance{NFProfile: NFProfile{
+		NFInstanceId:   uuid.New().String(),
+		NFType:         "UDM",
+		NFStatus:       "REGISTERED",
+		HeartBeatTimer: 10,
+		UdmInfo:        &UdmInfo{SupiRanges: []SupiRange{{Start: "460010000000000", End: "460019999999999"}}},
+	}}
+	for _, v := range []NFInstance{dead, live} {
+		nrf.instances[v.NFType] = append(nrf.instances[v.NFType], v)
+		nrf.identities.update(v.NFInstanceId, &v.NFProfile)
+	}
+	period := time.Duration(NRFConfigure.DefaultHeartBeatTimer+NRFConfigure.HeartBeatGracePeriod+NRFConfigure.HeartBeatDeregisterPeriod) * time.Second
+	nrf.heartbeats[dead.NFInstanceId] = now.Add(-period)
+	nrf.heartbeats[live.NFInstanceId] = now
+	// dead UDM is deregistered and removed from the identity index, the live one is kept
+	nrf.superviseHeartBeats(now)
+	assert.Len(t, nrf.instances["UDM"], 1)
+	assert.Equal(t, live.NFInstanceId, nrf.instances["UDM"][0].NFInstanceId)
+	assert.NotContains(t, nrf.identities.entries, dead.NFInstanceId)
+	assert.NotContains(t, nrf.identities.supi.owners, dead.NFInstanceId)
+	assert.Empty(t, nrf.identities.supi.lookup("imsi-460001000000500", "460001000000500"))
+	assert.Contains(t, nrf.identities.entries, live.NFInstanceId)
+	assert.True(t, nrf.identities.supi.owners[live.NFInstanceId])
+	assert.Equal(t, map[string]bool{live.NFInstanceId: true}, nrf.identities.supi.lookup("imsi-460011000000500", "460011000000500"))
+}
+
+func BenchmarkSuperviseHeartBeats(b *testing.B) {
+	// initialize NRF Service
+	nrf := New()
+	err := nrf.Init()
+	if err != nil {
+		b.Fatal("Error initialize NRF:", err)
+	}
+	// store instances in NRF Service database
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		instance := NFInstance{NFProfile: NFProfile{
+			NFInstanceId:   uuid.New().String(),
+			NFType:         "SMF",
+			NFStatus:       "REGISTERED",
+			HeartBeatTimer: 60,
+		}}
+		nrf.instances[instance.NFType] = append(nrf.instances[instance.NFType], instance)
+		nrf.heartbeats[instance.NFInstanceId] = now
+	}
+	// start benchmark test...
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nrf.superviseHeartBeats(now)
+	}
+}
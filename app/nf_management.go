@@ -1,18 +1,23 @@
 package app
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	. "nrf/conf"
 	. "nrf/data"
+	. "nrf/features"
 	. "nrf/logs"
+	. "nrf/util"
+	"reflect"
+	"sort"
 	"strings"
 )
 
 type NFProfileRetrieveRequest struct {
-	RequesterFeatures []string `form:"requester-features" binding:"omitempty,dive,ipv4,ipv6,tls,http2,service-auth"`
+	RequesterFeatures string `form:"requester-features" binding:"omitempty"`
 }
 
 type NFListRetrieveRequest struct {
@@ -22,6 +27,14 @@ type NFListRetrieveRequest struct {
 	PageSize   int    `form:"page-size" binding:"omitempty,min=1"`
 }
 
+func nfManagementFeatures() API {
+	// Shared-Data is advertised only when shared data is allowed
+	if !NRFConfigure.AllowedSharedData {
+		return NFManagement.Without("Shared-Data")
+	}
+	return NFManagement
+}
+
 func (nrf *NRF) HandleNFRegisterOrNFProfileCompleteReplacement(context *gin.Context) {
 	// extract nfInstanceId from request uri
 	nfInstanceId := strings.ToLower(context.Param("nfInstanceID"))
@@ -94,23 +107,36 @@ func (nrf *NRF) HandleNFRegister(context *gin.Context) {
 	// extract nfInstanceId from request uri
 	nfInstanceId := strings.ToLower(context.Param("nfInstanceID"))
 	fmt.Println("nfInstanceId:", nfInstanceId)
-	// create instance from request body
-	instance := NFInstance{
-		NFInstanceId:   nfInstanceId,
-		NFType:         response.NFType,
-		NFStatus:       response.NFStatus,
-		HeartBeatTimer: response.HeartBeatTimer,
-		NFServices:     response.NFServices,
+	// check If-Match precondition, which never matches a new resource
+	if !matchIfMatch(context, "") {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Precondition Failed"
+		problemDetails.Status = http.StatusPreconditionFailed
+		problemDetails.Detail = errPreconditionFailed.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusPreconditionFailed, problemDetails)
+		L.Error("NFRegister request precondition failed:", context.GetHeader("If-Match"))
+		return
 	}
+	// create instance from request body
+	instance := NFInstance{NFProfile: response}
+	instance.NFInstanceId = nfInstanceId
 	// store instance in NRF Service database
 	func() {
 		nrf.mutex.Lock()
 		defer nrf.mutex.Unlock()
 		nrf.instances[response.NFType] = append(nrf.instances[response.NFType], instance)
+		nrf.identities.update(nfInstanceId, &instance.NFProfile)
+		nrf.recordHeartBeat(nfInstanceId)
 	}()
-	// return success response
+	// notify subscribers NF registered
+	location := formLocation(context, "nnrf-nfm", "v1", "nf-instances", nfInstanceId)
+	nrf.notifyNFStatus("NF_REGISTERED", location, response)
+	// return success response with NRF supported features
+	response.NrfSupportedFeatures = nfManagementFeatures().Supported()
 	context.Header("Content-Type", "application/json")
-	context.Header("Location", formLocation(context, "nnrf-nfm", "v1", "nf-instances", nfInstanceId))
+	context.Header("Location", location)
+	context.Header("ETag", formETag(instance))
 	context.JSON(http.StatusCreated, response)
 	return
 }
@@ -161,21 +187,26 @@ func (nrf *NRF) HandleNFProfileCompleteReplacement(context *gin.Context) {
 	nfInstanceId := strings.ToLower(context.Param("nfInstanceID"))
 	fmt.Println("nfInstanceId:", nfInstanceId)
 	// create instance from request body
-	instance := NFInstance{
-		NFInstanceId:   nfInstanceId,
-		NFType:         response.NFType,
-		NFStatus:       response.NFStatus,
-		HeartBeatTimer: response.HeartBeatTimer,
-		NFServices:     response.NFServices,
-	}
+	instance := NFInstance{NFProfile: response}
+	instance.NFInstanceId = nfInstanceId
 	// store instance in NRF Service database
+	var changed bool
 	err = func(instance *NFInstance) (err error) {
 		nrf.mutex.Lock()
 		defer nrf.mutex.Unlock()
 		for _, instances := range nrf.instances {
 			for k, v := range instances {
 				if v.NFInstanceId == nfInstanceId {
+					// check If-Match precondition against the stored profile
+					if !matchIfMatch(context, formETag(v)) {
+						return errPreconditionFailed
+					}
+					changed = !reflect.DeepEqual(v, *instance)
 					instances[k], err = *instance, nil
+					if changed {
+						nrf.identities.update(nfInstanceId, &instance.NFProfile)
+					}
+					nrf.recordHeartBeat(nfInstanceId)
 					return err
 				}
 			}
@@ -183,6 +214,16 @@ func (nrf *NRF) HandleNFProfileCompleteReplacement(context *gin.Context) {
 		err = errors.New("NFInstance not found")
 		return err
 	}(&instance)
+	if errors.Is(err, errPreconditionFailed) {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Precondition Failed"
+		problemDetails.Status = http.StatusPreconditionFailed
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusPreconditionFailed, problemDetails)
+		L.Error("NFProfileCompleteReplacement request precondition failed:", context.GetHeader("If-Match"))
+		return
+	}
 	if err != nil {
 		var problemDetails ProblemDetails
 		problemDetails.Title = "Internal Server Error"
@@ -193,7 +234,219 @@ func (nrf *NRF) HandleNFProfileCompleteReplacement(context *gin.Context) {
 		L.Error("NFProfileCompleteReplacement profile complete replacement failed:", err)
 		return
 	}
-	// return success response
+	// notify subscribers NF profile changed
+	if changed {
+		nrf.notifyNFStatus("NF_PROFILE_CHANGED", formLocation(context, "nnrf-nfm", "v1", "nf-instances", nfInstanceId), response)
+	}
+	// return success response with NRF supported features
+	response.NrfSupportedFeatures = nfManagementFeatures().Supported()
+	context.Header("Content-Type", "application/json")
+	context.Header("ETag", formETag(instance))
+	context.JSON(http.StatusOK, response)
+	return
+}
+
+func (nrf *NRF) HandleNFUpdate(context *gin.Context) {
+	var request []PatchItem
+	// record context in logs
+	L.Info("NFUpdate request:", context.Request)
+	// check request content type
+	if context.ContentType() != "application/json-patch+json" {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Unsupported Media Type"
+		problemDetails.Status = http.StatusUnsupportedMediaType
+		problemDetails.Detail = errors.New("content type should be application/json-patch+json").Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusUnsupportedMediaType, problemDetails)
+		L.Error("NFUpdate request content type not supported:", context.ContentType())
+		return
+	}
+	// check request body bind json
+	L.Debug("Start bind NFUpdate request body to json:", context.Request.Body)
+	err := context.ShouldBindJSON(&request)
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFUpdate request body bind json failed:", err)
+		return
+	}
+	L.Debug("NFUpdate request body bind json success.")
+	// check patch items not modify immutable IEs
+	b, err := checkNFUpdatePatchItems(request)
+	if b == false && err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Forbidden"
+		problemDetails.Status = http.StatusForbidden
+		problemDetails.Detail = err.Error()
+		problemDetails.Cause = "MODIFICATION_NOT_ALLOWED"
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusForbidden, problemDetails)
+		L.Error("NFUpdate request check failed:", err)
+		return
+	}
+	// extract nfInstanceId from request uri
+	nfInstanceId := strings.ToLower(context.Param("nfInstanceID"))
+	L.Debug("nfInstanceId:", nfInstanceId)
+	// found instance in NRF Service database
+	var instance NFInstance
+	exists := func(instance *NFInstance) bool {
+		nrf.mutex.RLock()
+		defer nrf.mutex.RUnlock()
+		for _, instances := range nrf.instances {
+			for _, v := range instances {
+				if v.NFInstanceId == nfInstanceId {
+					*instance = v
+					return true
+				}
+			}
+		}
+		return false
+	}(&instance)
+	if !exists {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Not Found"
+		problemDetails.Status = http.StatusNotFound
+		problemDetails.Detail = errors.New("NFInstanceId not found").Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusNotFound, problemDetails)
+		L.Error("NFUpdate request NFInstance not found:", nfInstanceId)
+		return
+	}
+	// check If-Match precondition against the stored profile
+	if !matchIfMatch(context, formETag(instance)) {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Precondition Failed"
+		problemDetails.Status = http.StatusPreconditionFailed
+		problemDetails.Detail = errPreconditionFailed.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusPreconditionFailed, problemDetails)
+		L.Error("NFUpdate request precondition failed:", context.GetHeader("If-Match"))
+		return
+	}
+	// apply patch items on stored profile
+	original, err := json.Marshal(instance)
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Internal Server Error"
+		problemDetails.Status = http.StatusInternalServerError
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusInternalServerError, problemDetails)
+		L.Error("NFUpdate stored profile marshal failed:", err)
+		return
+	}
+	patched, err := ApplyJSONPatch(original, request)
+	if err != nil {
+		status, title := http.StatusBadRequest, "Bad Request"
+		if errors.Is(err, ErrJSONPatchTestFailed) {
+			status, title = http.StatusConflict, "Conflict"
+		}
+		var problemDetails ProblemDetails
+		problemDetails.Title = title
+		problemDetails.Status = status
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(status, problemDetails)
+		L.Error("NFUpdate request patch apply failed:", err)
+		return
+	}
+	var response NFProfile
+	err = json.Unmarshal(patched, &response)
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFUpdate patched profile unmarshal failed:", err)
+		return
+	}
+	// check patched profile IEs
+	b, err = checkNFRegisterIEs(&response)
+	if b == false && err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFUpdate patched profile check failed:", err)
+		return
+	}
+	// handle patched profile IEs
+	err = handleNFRegisterIEs(&response)
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Internal Server Error"
+		problemDetails.Status = http.StatusInternalServerError
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusInternalServerError, problemDetails)
+		L.Error("NFUpdate patched profile handle failed:", err)
+		return
+	}
+	// create instance from patched profile
+	updated := NFInstance{NFProfile: response}
+	updated.NFInstanceId = nfInstanceId
+	// store instance in NRF Service database
+	err = func(instance *NFInstance) (err error) {
+		nrf.mutex.Lock()
+		defer nrf.mutex.Unlock()
+		for _, instances := range nrf.instances {
+			for k, v := range instances {
+				if v.NFInstanceId == nfInstanceId {
+					// recheck If-Match precondition in case of concurrent modification
+					if !matchIfMatch(context, formETag(v)) {
+						return errPreconditionFailed
+					}
+					// heart-beat without profile change keeps the identity index
+					if !reflect.DeepEqual(v, *instance) {
+						nrf.identities.update(nfInstanceId, &instance.NFProfile)
+					}
+					instances[k], err = *instance, nil
+					nrf.recordHeartBeat(nfInstanceId)
+					return err
+				}
+			}
+		}
+		err = errors.New("NFInstance not found")
+		return err
+	}(&updated)
+	if errors.Is(err, errPreconditionFailed) {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Precondition Failed"
+		problemDetails.Status = http.StatusPreconditionFailed
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusPreconditionFailed, problemDetails)
+		L.Error("NFUpdate request precondition failed:", context.GetHeader("If-Match"))
+		return
+	}
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Not Found"
+		problemDetails.Status = http.StatusNotFound
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusNotFound, problemDetails)
+		L.Error("NFUpdate profile update failed:", err)
+		return
+	}
+	context.Header("ETag", formETag(updated))
+	// return 204 No Content when profile not changed (e.g. heart-beat)
+	if reflect.DeepEqual(instance, updated) {
+		context.Status(http.StatusNoContent)
+		return
+	}
+	// notify subscribers NF profile changed
+	nrf.notifyNFStatus("NF_PROFILE_CHANGED", formLocation(context, "nnrf-nfm", "v1", "nf-instances", nfInstanceId), response)
+	// return success response with NRF supported features
+	response.NrfSupportedFeatures = nfManagementFeatures().Supported()
 	context.Header("Content-Type", "application/json")
 	context.JSON(http.StatusOK, response)
 	return
@@ -201,29 +454,34 @@ func (nrf *NRF) HandleNFProfileCompleteReplacement(context *gin.Context) {
 
 func (nrf *NRF) HandleNFProfileRetrieve(context *gin.Context) {
 	var request NFProfileRetrieveRequest
-	var requestFeatureFilter bool
 	// record context in logs
 	L.Info("NFProfileRetrieve request:", context.Request)
-	// check request body bind json
-	L.Debug("Start bind NFProfileRetrieve request body to json:", context.Request.Body)
-	err := context.ShouldBindJSON(&request)
+	// check request query parameters
+	L.Debug("Start bind NFProfileRetrieve request query:", context.Request.URL.RawQuery)
+	err := context.ShouldBindQuery(&request)
+	if err == nil {
+		_, err = Parse(request.RequesterFeatures)
+	}
 	if err != nil {
-		requestFeatureFilter = false
-		L.Debug("NFProfileRetrieve request body bind json failed:", err.Error())
-		L.Debug("NFProfileRetrieve request-feature filter not allowed.")
-	} else {
-		requestFeatureFilter = true
-		L.Debug("NFProfileRetrieve request body bind json success.")
-		L.Debug("NFProfileRetrieve request-feature filter allowed.")
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFProfileRetrieve request query bind failed:", err)
+		return
 	}
 	// extract nfInstanceId from request uri
 	nfInstanceId := strings.ToLower(context.Param("nfInstanceID"))
 	fmt.Println("nfInstanceId:", nfInstanceId)
 	// found instance in NRF Service database
 	var response NFInstance
+	var requester nfRequester
 	exists := func(instance *NFInstance) bool {
 		nrf.mutex.RLock()
 		defer nrf.mutex.RUnlock()
+		requester = nrf.identifyRequester(context)
 		for _, instances := range nrf.instances {
 			for _, v := range instances {
 				if v.NFInstanceId == nfInstanceId {
@@ -244,22 +502,23 @@ func (nrf *NRF) HandleNFProfileRetrieve(context *gin.Context) {
 		L.Error("NFProfileRetrieve request NFInstance not found:", err)
 		return
 	}
-	// check match request features (request-feature filter allowed)
-	if requestFeatureFilter {
-		var supported []string
-		for _, v := range response.NFServices {
-			supported = append(supported, v.SupportedFeatures)
-		}
-		if !matchFeatures(request.RequesterFeatures, supported) {
-			var problemDetails ProblemDetails
-			problemDetails.Title = "Forbidden"
-			problemDetails.Status = http.StatusForbidden
-			problemDetails.Detail = errors.New("request Features not supported").Error()
-			context.Header("Content-Type", "application/problem+json")
-			context.JSON(http.StatusForbidden, problemDetails)
-			L.Error("NFProfileRetrieve request features not supported:", err)
-			return
-		}
+	// return 403 Forbidden when the requester is not allowed to access the profile
+	if !authorizeNFProfile(&requester, &response.NFProfile) {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Forbidden"
+		problemDetails.Status = http.StatusForbidden
+		problemDetails.Detail = errors.New("Requester is not allowed to access NFInstance").Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusForbidden, problemDetails)
+		L.Error("NFProfileRetrieve request forbidden:", requester.NFType, requester.NFInstanceId)
+		return
+	}
+	// entity tag of the stored profile, as If-Match of later updates is checked against it
+	context.Header("ETag", formETag(response))
+	filterNFServices(&requester, &response.NFProfile)
+	// negotiate supported features with requester, unknown bits are ignored
+	if request.RequesterFeatures != "" {
+		response.NrfSupportedFeatures, _ = nfManagementFeatures().Negotiate(request.RequesterFeatures)
 	}
 	// return success response
 	context.Header("Content-Type", "application/json")
@@ -275,27 +534,45 @@ func (nrf *NRF) HandleNFDeregister(context *gin.Context) {
 	nfInstanceId := strings.ToLower(context.Param("nfInstanceID"))
 	fmt.Println("nfInstanceId:", nfInstanceId)
 	// search and delete instance from database
-	exists := func(nfInstanceId string) bool {
+	var deleted NFInstance
+	exists, err := func(nfInstanceId string) (exists bool, err error) {
 		nrf.mutex.Lock()
 		defer nrf.mutex.Unlock()
 		// search the specific instance in database
-		exists := false
 		for k, v := range nrf.instances {
 			for i, j := range v {
 				if j.NFInstanceId == nfInstanceId {
+					// check If-Match precondition against the stored profile
+					if !matchIfMatch(context, formETag(j)) {
+						return true, errPreconditionFailed
+					}
+					deleted = j
 					// delete NFInstance from database
 					nrf.instances[k] = append(nrf.instances[k][:i], nrf.instances[k][i+1:]...)
+					nrf.identities.update(nfInstanceId, nil)
+					nrf.evictSearches(nfInstanceId)
+					delete(nrf.heartbeats, nfInstanceId)
 					// remove NFType slice when all NFInstance deleted
 					if len(nrf.instances[k]) == 0 {
 						delete(nrf.instances, k)
 					}
-					exists = true
-					break
+					return true, err
 				}
 			}
 		}
-		return exists
+		return exists, err
 	}(nfInstanceId)
+	// return 412 Precondition Failed
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Precondition Failed"
+		problemDetails.Status = http.StatusPreconditionFailed
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusPreconditionFailed, problemDetails)
+		L.Error("NFDeregister request precondition failed:", context.GetHeader("If-Match"))
+		return
+	}
 	// return 404 Not Found
 	if !exists {
 		var problemDetails ProblemDetails
@@ -307,6 +584,8 @@ func (nrf *NRF) HandleNFDeregister(context *gin.Context) {
 		L.Error("NFDeregister request NFInstanceId not found in database.")
 		return
 	}
+	// notify subscribers NF deregistered
+	nrf.notifyNFStatus("NF_DEREGISTERED", formLocation(context, "nnrf-nfm", "v1", "nf-instances", nfInstanceId), deleted.NFProfile)
 	// return 204 No Content
 	context.Status(http.StatusNoContent)
 }
@@ -334,34 +613,58 @@ func (nrf *NRF) HandleNFListRetrieve(context *gin.Context) {
 	response, err := func(request NFListRetrieveRequest) (uriList UriList, err error) {
 		nrf.mutex.RLock()
 		defer nrf.mutex.RUnlock()
-		// get instances according to nfType
+		// collect instances of the requested nfType, or of all nfTypes in stable order
+		var nfTypes []string
 		if request.NFType != "" {
-			// search specific nfType
-			for k, v := range nrf.instances {
-				if k == request.NFType {
-					// start and end points in slices
-					start := (request.PageNumber - 1) * request.PageSize
-					end := request.PageNumber + request.PageSize
-					// check validation of slices
-					if start >= len(v) {
-						err = errors.New("NFListRetrieveRequest start index out of bounds")
-						return
-					}
-					if end > len(v) {
-						end = len(v)
-					}
-					if (end - start) > request.Limit {
-						end = start + request.Limit
-					}
-					// retrieve NFs uri list
-					uriList.TotalItemCount = end - start
-					for _, j := range v[start:end] {
-						uriList.Links = append(uriList.Links, formLocation(context, "nnrf-nfm", "v1", "nf-instances", j.NFInstanceId))
-					}
-					break
+			nfTypes = append(nfTypes, request.NFType)
+		} else {
+			for k := range nrf.instances {
+				nfTypes = append(nfTypes, k)
+			}
+			sort.Strings(nfTypes)
+		}
+		// hide instances the requester is not allowed to access
+		requester := nrf.identifyRequester(context)
+		var nfInstanceIds []string
+		for _, nfType := range nfTypes {
+			for _, v := range nrf.instances[nfType] {
+				if authorizeNFProfile(&requester, &v.NFProfile) {
+					nfInstanceIds = append(nfInstanceIds, v.NFInstanceId)
 				}
 			}
 		}
+		uriList.TotalItemCount = len(nfInstanceIds)
+		uriList.Links.Item = []Link{}
+		// start and end points of the requested page
+		pageSize := request.PageSize
+		if pageSize == 0 {
+			pageSize = len(nfInstanceIds)
+		}
+		start := (request.PageNumber - 1) * pageSize
+		end := start + pageSize
+		// check validation of slices
+		if len(nfInstanceIds) != 0 && start >= len(nfInstanceIds) {
+			err = errors.New("NFListRetrieveRequest page-number out of bounds")
+			return
+		}
+		if end > len(nfInstanceIds) {
+			end = len(nfInstanceIds)
+		}
+		if request.Limit != 0 && (end-start) > request.Limit {
+			end = start + request.Limit
+		}
+		// retrieve NFs uri list
+		for _, v := range nfInstanceIds[start:end] {
+			uriList.Links.Item = append(uriList.Links.Item, Link{Href: formLocation(context, "nnrf-nfm", "v1", "nf-instances", v)})
+		}
+		// form self, next and prev page links
+		uriList.Links.Self = formPageLink(context, request.PageNumber)
+		if start+pageSize < len(nfInstanceIds) {
+			uriList.Links.Next = formPageLink(context, request.PageNumber+1)
+		}
+		if request.PageNumber > 1 {
+			uriList.Links.Prev = formPageLink(context, request.PageNumber-1)
+		}
 		return uriList, err
 	}(request)
 	if err != nil {
@@ -372,6 +675,7 @@ func (nrf *NRF) HandleNFListRetrieve(context *gin.Context) {
 		context.Header("Content-Type", "application/problem+json")
 		context.JSON(http.StatusNotFound, problemDetails)
 		L.Error("NFListRetrieve request query UriList not found:", err)
+		return
 	}
 	// return success response
 	context.Header("Content-Type", "application/3gppHal+json")
@@ -457,6 +761,17 @@ func (nrf *NRF) HandleNFRegisterSharedData(context *gin.Context) {
 	// extract sharedDataId from request uri
 	sharedDataId := strings.ToLower(context.Param("sharedDataId"))
 	fmt.Println("sharedDataId:", sharedDataId)
+	// check If-Match precondition, which never matches a new resource
+	if !matchIfMatch(context, "") {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Precondition Failed"
+		problemDetails.Status = http.StatusPreconditionFailed
+		problemDetails.Detail = errPreconditionFailed.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusPreconditionFailed, problemDetails)
+		L.Error("NFRegister (SharedData) request precondition failed:", context.GetHeader("If-Match"))
+		return
+	}
 	// create repository from request body
 	repository := SharedRepository{
 		SharedDataId:      sharedDataId,
@@ -472,6 +787,7 @@ func (nrf *NRF) HandleNFRegisterSharedData(context *gin.Context) {
 	// return success response
 	context.Header("Content-Type", "application/json")
 	context.Header("Location", formLocation(context, "nnrf-nfm", "v1", "shared-data", sharedDataId))
+	context.Header("ETag", formETag(repository))
 	context.JSON(http.StatusCreated, response)
 	return
 }
@@ -534,6 +850,10 @@ func (nrf *NRF) HandleNFSharedDataCompleteReplacement(context *gin.Context) {
 		for _, repositories := range nrf.repositories {
 			for k, v := range repositories {
 				if v.SharedDataId == sharedDataId {
+					// check If-Match precondition against the stored shared data
+					if !matchIfMatch(context, formETag(v)) {
+						return errPreconditionFailed
+					}
 					repositories[k], err = *repo, nil
 					return err
 				}
@@ -542,6 +862,16 @@ func (nrf *NRF) HandleNFSharedDataCompleteReplacement(context *gin.Context) {
 		err = errors.New("SharedRepositories not found")
 		return err
 	}(&repository)
+	if errors.Is(err, errPreconditionFailed) {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Precondition Failed"
+		problemDetails.Status = http.StatusPreconditionFailed
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusPreconditionFailed, problemDetails)
+		L.Error("NFSharedDataCompleteReplacement request precondition failed:", context.GetHeader("If-Match"))
+		return
+	}
 	if err != nil {
 		var problemDetails ProblemDetails
 		problemDetails.Title = "Internal Server Error"
@@ -554,26 +884,30 @@ func (nrf *NRF) HandleNFSharedDataCompleteReplacement(context *gin.Context) {
 	}
 	// return success response
 	context.Header("Content-Type", "application/json")
+	context.Header("ETag", formETag(repository))
 	context.JSON(http.StatusOK, response)
 	return
 }
 
 func (nrf *NRF) HandleNFSharedDataRetrieve(context *gin.Context) {
 	var request NFProfileRetrieveRequest
-	var requestFeatureFilter bool
 	// record context in logs
 	L.Info("NFSharedDataRetrieve request:", context.Request)
-	// check request body bind json
-	L.Debug("Start bind NFSharedDataRetrieve request body to json:", context.Request.Body)
-	err := context.ShouldBindJSON(&request)
+	// check request query parameters
+	L.Debug("Start bind NFSharedDataRetrieve request query:", context.Request.URL.RawQuery)
+	err := context.ShouldBindQuery(&request)
+	if err == nil {
+		_, err = Parse(request.RequesterFeatures)
+	}
 	if err != nil {
-		requestFeatureFilter = false
-		L.Debug("NFSharedDataRetrieve request body bind json failed:", err.Error())
-		L.Debug("NFSharedDataRetrieve request-feature filter not allowed.")
-	} else {
-		requestFeatureFilter = true
-		L.Debug("NFSharedDataRetrieve request body bind json success.")
-		L.Debug("NFSharedDataRetrieve request-feature filter allowed.")
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFSharedDataRetrieve request query bind failed:", err)
+		return
 	}
 	// extract sharedDataId from request uri
 	sharedDataId := strings.ToLower(context.Param("sharedDataId"))
@@ -603,26 +937,9 @@ func (nrf *NRF) HandleNFSharedDataRetrieve(context *gin.Context) {
 		L.Error("NFSharedDataRetrieve request SharedData not found:", err)
 		return
 	}
-	// check match request features (request-feature filter allowed)
-	if requestFeatureFilter {
-		var supported []string
-		// SharedProfileData and SharedServiceData are conditional...
-		for _, v := range response.SharedProfileData.NFServices {
-			supported = append(supported, v.SupportedFeatures)
-		}
-		if !matchFeatures(request.RequesterFeatures, supported) {
-			var problemDetails ProblemDetails
-			problemDetails.Title = "Forbidden"
-			problemDetails.Status = http.StatusForbidden
-			problemDetails.Detail = errors.New("request Features not supported").Error()
-			context.Header("Content-Type", "application/problem+json")
-			context.JSON(http.StatusForbidden, problemDetails)
-			L.Error("NFSharedDataRetrieve request features not supported:", err)
-			return
-		}
-	}
 	// return success response
 	context.Header("Content-Type", "application/json")
+	context.Header("ETag", formETag(response))
 	context.Header("Cache-Control", "no-cache")
 	context.JSON(http.StatusOK, response)
 	return
@@ -635,27 +952,40 @@ func (nrf *NRF) HandleNFDeregisterSharedData(context *gin.Context) {
 	sharedDataId := strings.ToLower(context.Param("sharedDataId"))
 	fmt.Println("sharedDataId:", sharedDataId)
 	// search and delete instance from database
-	exists := func(sharedDataId string) bool {
+	exists, err := func(sharedDataId string) (exists bool, err error) {
 		nrf.mutex.Lock()
 		defer nrf.mutex.Unlock()
 		// search the specific instance in database
-		exists := false
 		for k, v := range nrf.repositories {
 			for i, j := range v {
 				if j.SharedDataId == sharedDataId {
+					// check If-Match precondition against the stored shared data
+					if !matchIfMatch(context, formETag(j)) {
+						return true, errPreconditionFailed
+					}
 					// delete SharedData from database
 					nrf.repositories[k] = append(nrf.repositories[k][:i], nrf.repositories[k][i+1:]...)
 					// remove SharedDataId slice when all SharedData deleted
 					if len(nrf.repositories[k]) == 0 {
 						delete(nrf.repositories, k)
 					}
-					exists = true
-					break
+					return true, err
 				}
 			}
 		}
-		return exists
+		return exists, err
 	}(sharedDataId)
+	// return 412 Precondition Failed
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Precondition Failed"
+		problemDetails.Status = http.StatusPreconditionFailed
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusPreconditionFailed, problemDetails)
+		L.Error("NFDeregister (SharedData) request precondition failed:", context.GetHeader("If-Match"))
+		return
+	}
 	// return 404 Not Found
 	if !exists {
 		var problemDetails ProblemDetails
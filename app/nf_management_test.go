@@ -9,7 +9,9 @@ import (
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	. "nrf/conf"
 	. "nrf/data"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -38,11 +40,23 @@ func setupTestRouter() *gin.Engine {
 		nfManagement.GET("nf-instances", nrf.HandleNFListRetrieve)
 		nfManagement.PUT("nf-instances/:nfInstanceID", nrf.HandleNFRegisterOrNFProfileCompleteReplacement)
 		nfManagement.GET("nf-instances/:nfInstanceID", nrf.HandleNFProfileRetrieve)
+		nfManagement.PATCH("nf-instances/:nfInstanceID", nrf.HandleNFUpdate)
 		nfManagement.DELETE("nf-instances/:nfInstanceID", nrf.HandleNFDeregister)
 		nfManagement.PUT("shared-data/:sharedDataId", nrf.HandleNFRegisterOrNFSharedDataCompleteReplacement)
 		nfManagement.GET("shared-data/:sharedDataId", nrf.HandleNFSharedDataRetrieve)
 		nfManagement.DELETE("shared-data/:sharedDataId", nrf.HandleNFDeregisterSharedData)
+		nfManagement.POST("subscriptions", nrf.HandleNFStatusSubscribe)
+		nfManagement.DELETE("subscriptions/:subscriptionID", nrf.HandleNFStatusUnsubscribe)
 	}
+	nfDiscovery := router.Group("/nnrf-disc/v1")
+	{
+		nfDiscovery.GET("nf-instances", nrf.HandleNFDiscover)
+		nfDiscovery.GET("searches/:searchId", nrf.HandleSearchRetrieve)
+		nfDiscovery.GET("searches/:searchId/complete", nrf.HandleSearchCompleteRetrieve)
+	}
+	// OAuth2 authorization service
+	router.POST("/oauth2/token", nrf.HandleAccessToken)
+	router.GET("/oauth2/jwks", nrf.HandleJWKSRetrieve)
 	return router
 }
 
@@ -411,6 +425,152 @@ func FuzzHandleNFRegisterWithUpperNFInstanceID(f *testing.F) {
 	})
 }
 
+func TestHandleNFRegisterWithNFServices(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	nfInstanceId := uuid.New().String()
+	// assemble network function http request
+	service := NFService{
+		ServiceInstanceId: "1",
+		ServiceName:       "namf-comm",
+		Versions:          []NFServiceVersion{{ApiVersionInUri: "v1", ApiFullVersion: "1.2.0"}},
+		Scheme:            "https",
+		NFServiceStatus:   "REGISTERED",
+		Fqdn:              "amf1.5gc.mnc000.mcc460.3gppnetwork.org",
+		IpEndPoints:       []IpEndPoint{{Ipv4Address: "10.0.0.1", Transport: "TCP", Port: 443}},
+		ApiPrefix:         "https://amf1.5gc.mnc000.mcc460.3gppnetwork.org/prefix",
+		AllowedNfTypes:    []string{"SMF"},
+		Priority:          1,
+		Capacity:          100,
+		Load:              10,
+		Oauth2Required:    true,
+	}
+	profile := NFProfile{
+		NFInstanceId: nfInstanceId,
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		NFServices:   []NFService{service},
+	}
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	// http request NFRegister
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	var response NFProfile
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	// assert http response
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, []NFService{service}, response.NFServices)
+	// assemble network function http request without mandatory service IEs
+	for _, invalid := range []NFService{
+		{ServiceInstanceId: "1", Scheme: "https", Versions: service.Versions},
+		{ServiceInstanceId: "1", ServiceName: "namf-comm", Versions: service.Versions},
+		{ServiceInstanceId: "1", ServiceName: "namf-comm", Scheme: "https"},
+	} {
+		nfInstanceId = uuid.New().String()
+		profile.NFInstanceId = nfInstanceId
+		profile.NFServices = []NFService{invalid}
+		body, err = json.Marshal(profile)
+		if err != nil {
+			t.Errorf("Error marshalling profile: %v", err)
+		}
+		// http request NFRegister
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, request)
+		// assert http response
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestHandleNFRegisterWithNFInfo(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	plmnId := PlmnId{Mcc: "460", Mnc: "00"}
+	amfInfo := AmfInfo{
+		AmfSetId:    "001",
+		AmfRegionId: "01",
+		GuamiList:   []Guami{{PlmnId: plmnId, AmfId: "010041"}},
+		TaiList:     []Tai{{PlmnId: plmnId, Tac: "000001"}},
+	}
+	smfInfo := SmfInfo{
+		SNssaiSmfInfoList: []SnssaiSmfInfoItem{{SNssai: Snssai{Sst: 1}, DnnSmfInfoList: []DnnSmfInfoItem{{Dnn: "internet"}}}},
+	}
+	// assemble network function http request
+	cases := []struct {
+		profile NFProfile
+		status  int
+	}{
+		{NFProfile{NFType: "AMF", AmfInfo: &amfInfo}, http.StatusCreated},
+		{NFProfile{NFType: "AMF", AmfInfoList: map[string]AmfInfo{"1": amfInfo}}, http.StatusCreated},
+		{NFProfile{NFType: "SMF", SmfInfo: &smfInfo}, http.StatusCreated},
+		{NFProfile{NFType: "AMF", SmfInfo: &smfInfo}, http.StatusBadRequest},
+		{NFProfile{NFType: "SMF", AmfInfoList: map[string]AmfInfo{"1": amfInfo}}, http.StatusBadRequest},
+		{NFProfile{NFType: "AMF", AmfInfo: &AmfInfo{AmfSetId: "001", AmfRegionId: "01"}}, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		nfInstanceId := uuid.New().String()
+		profile := c.profile
+		profile.NFInstanceId = nfInstanceId
+		profile.NFStatus = "REGISTERED"
+		body, err := json.Marshal(profile)
+		if err != nil {
+			t.Errorf("Error marshalling profile: %v", err)
+		}
+		// http request NFRegister
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, request)
+		// assert http response
+		assert.Equal(t, c.status, w.Code)
+		if w.Code != http.StatusCreated {
+			continue
+		}
+		// http request NFProfileRetrieve
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodGet, url+"/"+nfInstanceId, nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		router.ServeHTTP(w, request)
+		var response NFProfile
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		// assert http response
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, profile.AmfInfo, response.AmfInfo)
+		assert.Equal(t, profile.AmfInfoList, response.AmfInfoList)
+		assert.Equal(t, profile.SmfInfo, response.SmfInfo)
+	}
+}
+
 func TestHandleNFRegisterWithoutNFType(t *testing.T) {
 	// start http test service
 	server, router := startTestServer()
@@ -883,6 +1043,345 @@ func FuzzHandleNFProfileCompleteReplacement(f *testing.F) {
 	})
 }
 
+func TestHandleNFUpdate(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	nfInstanceId := uuid.New().String()
+	nfType := "AMF"
+	nfStatus := "REGISTERED"
+	// assemble network function http request
+	profile := NFProfile{
+		NFInstanceId: nfInstanceId,
+		NFType:       nfType,
+		NFStatus:     nfStatus,
+	}
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	// http request NFRegister
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	var response NFProfile
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	// assert http response
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, nfInstanceId, response.NFInstanceId)
+	assert.Equal(t, nfStatus, response.NFStatus)
+	// assemble network function patch request
+	nfStatusNew := "SUSPENDED"
+	patch := []PatchItem{
+		{Op: "test", Path: "/nfStatus", Value: nfStatus},
+		{Op: "replace", Path: "/nfStatus", Value: nfStatusNew},
+	}
+	bodyNew, err := json.Marshal(patch)
+	if err != nil {
+		t.Errorf("Error marshalling patch: %v", err)
+	}
+	// http request NFUpdate
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPatch, url+"/"+nfInstanceId, bytes.NewReader(bodyNew))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json-patch+json")
+	router.ServeHTTP(w, request)
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	// assert http response
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, nfInstanceId, response.NFInstanceId)
+	assert.Equal(t, nfType, response.NFType)
+	assert.Equal(t, nfStatusNew, response.NFStatus)
+}
+
+func TestHandleNFProfileETag(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFProfileETag
+	// Test Purpose: Test ETag with If-Match and If-None-Match on nf-instances
+	// Test Steps:
+	// 1. send NFRegister request and receive ETag
+	// 2. send NFProfileRetrieve request with If-None-Match and receive 304
+	// 3. send NFProfileCompleteReplacement request with stale If-Match and receive 412
+	// 4. send NFProfileCompleteReplacement request with current If-Match and receive new ETag
+	// 5. send NFUpdate and NFDeregister requests with stale If-Match and receive 412
+	// 6. send NFDeregister request with current If-Match and receive 204
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	nfInstanceId := uuid.New().String()
+	profile := NFProfile{
+		NFInstanceId: nfInstanceId,
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+	}
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	// http request NFRegister
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	// http request NFProfileRetrieve
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url+"/"+nfInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	// http request NFProfileRetrieve with If-None-Match
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url+"/"+nfInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("If-None-Match", etag)
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	// http request NFProfileCompleteReplacement with stale If-Match
+	profile.NFStatus = "SUSPENDED"
+	body, err = json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", `"stale"`)
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	// http request NFProfileCompleteReplacement with current If-Match
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", etag)
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etagNew := w.Header().Get("ETag")
+	assert.NotEmpty(t, etagNew)
+	assert.NotEqual(t, etag, etagNew)
+	// http request NFUpdate with stale If-Match
+	patch := []PatchItem{
+		{Op: "replace", Path: "/nfStatus", Value: "REGISTERED"},
+	}
+	bodyNew, err := json.Marshal(patch)
+	if err != nil {
+		t.Errorf("Error marshalling patch: %v", err)
+	}
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPatch, url+"/"+nfInstanceId, bytes.NewReader(bodyNew))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json-patch+json")
+	request.Header.Set("If-Match", etag)
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	// http request NFDeregister with stale If-Match
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodDelete, url+"/"+nfInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("If-Match", etag)
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	// http request NFDeregister with current If-Match
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodDelete, url+"/"+nfInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("If-Match", etagNew)
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func BenchmarkHandleNFUpdate(b *testing.B) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// start benchmark test...
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// construct network function request content
+		rand.New(rand.NewSource(time.Now().UnixNano()))
+		url := server.URL + "/nnrf-nfm/v1/nf-instances"
+		nfInstanceId := uuid.New().String()
+		nfType := NetworkFunctionType[rand.Intn(len(NetworkFunctionType))]
+		nfStatus := NetworkFunctionStatus[rand.Intn(len(NetworkFunctionStatus))]
+		// assemble network function http request
+		profile := NFProfile{
+			NFInstanceId: nfInstanceId,
+			NFType:       nfType,
+			NFStatus:     nfStatus,
+		}
+		body, err := json.Marshal(profile)
+		if err != nil {
+			b.Errorf("Error marshalling profile: %v", err)
+		}
+		// http request NFRegister
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+		if err != nil {
+			b.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, request)
+		assert.Equal(b, http.StatusCreated, w.Code)
+		// assemble network function patch request
+		nfStatusNew := NetworkFunctionStatus[rand.Intn(len(NetworkFunctionStatus))]
+		patch := []PatchItem{
+			{Op: "replace", Path: "/nfStatus", Value: nfStatusNew},
+		}
+		bodyNew, err := json.Marshal(patch)
+		if err != nil {
+			b.Errorf("Error marshalling patch: %v", err)
+		}
+		// http request NFUpdate
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodPatch, url+"/"+nfInstanceId, bytes.NewReader(bodyNew))
+		if err != nil {
+			b.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json-patch+json")
+		router.ServeHTTP(w, request)
+		// assert http response
+		if nfStatusNew == nfStatus {
+			assert.Equal(b, http.StatusNoContent, w.Code)
+		} else {
+			assert.Equal(b, http.StatusOK, w.Code)
+		}
+	}
+}
+
+func TestHandleNFUpdateHeartBeat(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	nfInstanceId := uuid.New().String()
+	nfType := "SMF"
+	nfStatus := "REGISTERED"
+	// assemble network function http request
+	profile := NFProfile{
+		NFInstanceId: nfInstanceId,
+		NFType:       nfType,
+		NFStatus:     nfStatus,
+	}
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	// http request NFRegister
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	// assemble network function heart-beat request
+	bodyNew := []byte(`[{"op":"replace","path":"/nfStatus","value":"` + nfStatus + `"}]`)
+	// http request NFUpdate
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPatch, url+"/"+nfInstanceId, bytes.NewReader(bodyNew))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json-patch+json")
+	router.ServeHTTP(w, request)
+	// assert http response
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+}
+
+func TestHandleNFUpdateImmutable(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	nfInstanceId := uuid.New().String()
+	nfType := "AMF"
+	nfStatus := "REGISTERED"
+	// assemble network function http request
+	profile := NFProfile{
+		NFInstanceId: nfInstanceId,
+		NFType:       nfType,
+		NFStatus:     nfStatus,
+	}
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	// http request NFRegister
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	// assemble network function patch request modify nfType
+	bodyNew := []byte(`[{"op":"replace","path":"/nfType","value":"SMF"}]`)
+	// http request NFUpdate
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPatch, url+"/"+nfInstanceId, bytes.NewReader(bodyNew))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json-patch+json")
+	router.ServeHTTP(w, request)
+	var problemDetails ProblemDetails
+	err = json.Unmarshal(w.Body.Bytes(), &problemDetails)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	// assert http response
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "MODIFICATION_NOT_ALLOWED", problemDetails.Cause)
+}
+
 func TestHandleNFProfileRetrieve(t *testing.T) {
 	// start http test service
 	server, router := startTestServer()
@@ -941,6 +1440,89 @@ func TestHandleNFProfileRetrieve(t *testing.T) {
 	assert.Equal(t, nfStatus, response.NFStatus)
 }
 
+func TestHandleNFProfileRetrieveWithRequesterFeatures(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFProfileRetrieveWithRequesterFeatures
+	// Test Purpose: Test HandleNFProfileRetrieve negotiates supported features
+	// Test Steps:
+	// 1. send NFRegister request and receive nrfSupportedFeatures of nnrf-nfm
+	// 2. send NFProfileRetrieve request with requester-features including unknown bits
+	// 3. receive 200 OK with the negotiated nrfSupportedFeatures
+	// 4. send NFProfileRetrieve request with invalid requester-features and receive 400
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	nfInstanceId := uuid.New().String()
+	profile := NFProfile{
+		NFInstanceId: nfInstanceId,
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+	}
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	// http request NFRegister
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	var response NFProfile
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", response.NrfSupportedFeatures)
+	// http request NFProfileRetrieve with requester-features, Shared-Data follows allowedSharedData
+	for allowedSharedData, supportedFeatures := range map[bool]string{false: "2", true: "6"} {
+		NRFConfigure.AllowedSharedData = allowedSharedData
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodGet, url+"/"+nfInstanceId+"?requester-features=F7", nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		router.ServeHTTP(w, request)
+		response = NFProfile{}
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, supportedFeatures, response.NrfSupportedFeatures)
+	}
+	NRFConfigure.AllowedSharedData = false
+	// http request NFProfileRetrieve without requester-features
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url+"/"+nfInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	response = NFProfile{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, response.NrfSupportedFeatures)
+	// http request NFProfileRetrieve with invalid requester-features
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url+"/"+nfInstanceId+"?requester-features=ipv4", nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+}
+
 func BenchmarkHandleNFProfileRetrieve(b *testing.B) {
 	// start http test service
 	server, router := startTestServer()
@@ -1123,6 +1705,192 @@ func FuzzHandleNFProfileRetrieve(f *testing.F) {
 	})
 }
 
+func TestHandleNFProfileRetrieveWithFullProfile(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	nfInstanceId := uuid.New().String()
+	recoveryTime := time.Now().UTC().Truncate(time.Second)
+	// assemble network function http request
+	profile := NFProfile{
+		NFInstanceId:         nfInstanceId,
+		NFType:               "SMF",
+		NFStatus:             "REGISTERED",
+		PlmnList:             []PlmnId{{Mcc: "460", Mnc: "00"}},
+		SNssais:              []Snssai{{Sst: 1, Sd: "000001"}},
+		PerPlmnSnssaiList:    []PlmnSnssai{{PlmnId: PlmnId{Mcc: "460", Mnc: "00"}, SNssaiList: []Snssai{{Sst: 1}}}},
+		NsiList:              []string{"nsi-1"},
+		Fqdn:                 "smf1.5gc.mnc000.mcc460.3gppnetwork.org",
+		Ipv4Addresses:        []string{"10.0.0.1"},
+		Ipv6Addresses:        []string{"2001:db8::1"},
+		AllowedPlmns:         []PlmnId{{Mcc: "460", Mnc: "01"}},
+		AllowedNfTypes:       []string{"AMF"},
+		AllowedNssais:        []Snssai{{Sst: 1, Sd: "000001"}},
+		Priority:             10,
+		Capacity:             100,
+		Load:                 50,
+		Locality:             "beijing",
+		RecoveryTime:         &recoveryTime,
+		NFServicePersistence: true,
+	}
+	body, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Error marshalling profile: %v", err)
+	}
+	// http request NFRegister
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	// register an AMF of the allowed PLMN and S-NSSAI
+	amf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "AMF",
+		NFStatus:     "REGISTERED",
+		PlmnList:     []PlmnId{{Mcc: "460", Mnc: "01"}},
+		SNssais:      []Snssai{{Sst: 1, Sd: "000001"}},
+	}
+	registerTestNFProfile(t, router, amf)
+	// http request NFProfileRetrieve from an allowed NF
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url+"/"+nfInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("User-Agent", "AMF-"+amf.NFInstanceId)
+	router.ServeHTTP(w, request)
+	var response NFProfile
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	// assert http response
+	profile.HeartBeatTimer = response.HeartBeatTimer
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, profile, response)
+}
+
+func TestHandleNFProfileRetrieveWithAllowedRequesters(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFProfileRetrieveWithAllowedRequesters
+	// Test Purpose: Test NFProfileRetrieve and NFListRetrieve enforce allowed* restrictions
+	// Test Steps:
+	// 1. register an SMF allowing AMF and PCF, with a service allowing AMF only
+	// 2. send NFProfileRetrieve and NFListRetrieve identified by User-Agent
+	// 3. receive 403 Forbidden or hidden instances for requesters not allowed
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	service1 := testNFService("1", "nsmf-pdusession")
+	service1.AllowedNfTypes = []string{"AMF"}
+	service2 := testNFService("2", "nsmf-event-exposure")
+	smf := NFProfile{
+		NFInstanceId:     uuid.New().String(),
+		NFType:           "SMF",
+		NFStatus:         "REGISTERED",
+		AllowedNfTypes:   []string{"AMF", "PCF"},
+		AllowedNfDomains: []string{`^[a-z0-9]+\.operator\.com$`},
+		NFServices:       []NFService{service1, service2},
+	}
+	registerTestNFProfile(t, router, smf)
+	// http request NFProfileRetrieve and NFListRetrieve with different requesters
+	etags := make(map[string]bool)
+	for userAgent, expected := range map[string]int{
+		"":                   http.StatusForbidden,
+		"Go-http-client/1.1": http.StatusForbidden,
+		"AMF-" + uuid.New().String() + " amf1.operator.com":  http.StatusOK,
+		"PCF-" + uuid.New().String() + " pcf1.operator.com.": http.StatusOK,
+		"UDM-" + uuid.New().String() + " udm1.operator.com":  http.StatusForbidden,
+		"AMF-" + uuid.New().String() + " amf1.other.com":     http.StatusForbidden,
+		// User-Agent does not prove the requester owns the profile
+		"SMF-" + smf.NFInstanceId: http.StatusForbidden,
+	} {
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/nnrf-nfm/v1/nf-instances/"+smf.NFInstanceId, nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("User-Agent", userAgent)
+		router.ServeHTTP(w, request)
+		assert.Equal(t, expected, w.Code, userAgent)
+		if w.Code == http.StatusOK {
+			var response NFProfile
+			err = json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Errorf("Error unmarshalling response: %v", err)
+			}
+			// services not allowed are hidden from other NFs
+			switch {
+			case strings.HasPrefix(userAgent, "PCF"):
+				assert.Equal(t, []NFService{service2}, response.NFServices, userAgent)
+			default:
+				assert.Len(t, response.NFServices, 2, userAgent)
+			}
+			// entity tag is the one of the stored profile, whatever services are hidden
+			etags[w.Header().Get("ETag")] = true
+		} else {
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		}
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodGet, "/nnrf-nfm/v1/nf-instances?nf-type=SMF", nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("User-Agent", userAgent)
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var uriList UriList
+		err = json.Unmarshal(w.Body.Bytes(), &uriList)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		assert.Equal(t, map[bool]int{true: 1, false: 0}[expected == http.StatusOK], uriList.TotalItemCount, userAgent)
+	}
+	assert.Len(t, etags, 1)
+}
+
+func TestHandleNFRegisterWithInvalidProfile(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	nfInstanceId := uuid.New().String()
+	// assemble network function http request with invalid IEs
+	profiles := []NFProfile{
+		{NFInstanceId: nfInstanceId, NFType: "SMF", NFStatus: "REGISTERED", Ipv4Addresses: []string{"10.0.0"}},
+		{NFInstanceId: nfInstanceId, NFType: "SMF", NFStatus: "REGISTERED", PlmnList: []PlmnId{{Mcc: "46", Mnc: "00"}}},
+		{NFInstanceId: nfInstanceId, NFType: "SMF", NFStatus: "REGISTERED", Load: 101},
+		{NFInstanceId: nfInstanceId, NFType: "SMF", NFStatus: "REGISTERED", AllowedNfTypes: []string{"XXX"}},
+		{NFInstanceId: nfInstanceId, NFType: "SMF", NFStatus: "REGISTERED", AllowedNfDomains: []string{"(operator"}},
+	}
+	for _, profile := range profiles {
+		body, err := json.Marshal(profile)
+		if err != nil {
+			t.Errorf("Error marshalling profile: %v", err)
+		}
+		// http request NFRegister
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, request)
+		// assert http response
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	}
+}
+
 func TestHandleNFDeregister(t *testing.T) {
 	/*-----------------------------------------------------------------------
 	// Test Case: TestHandleNFDeregister
@@ -2553,10 +3321,130 @@ func TestHandleNFListRetrieve(t *testing.T) {
 	// assert http response
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "application/3gppHal+json", w.Header().Get("Content-Type"))
-	assert.Equal(t, url+"/"+nfInstanceId, responseNew.Links[0])
+	assert.Equal(t, url+"/"+nfInstanceId, responseNew.Links.Item[0].Href)
 	assert.Equal(t, 1, responseNew.TotalItemCount)
 }
 
+func TestHandleNFListRetrieveWithPaging(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleNFListRetrieveWithPaging
+	// Test Purpose: Test HandleNFListRetrieve pages over all nfTypes
+	// Test Steps:
+	// 1. send NFListRetrieve request to an empty NRF and receive an empty list
+	// 2. register 3 AMF and 2 SMF network functions
+	// 3. send NFListRetrieve requests without nf-type and with page-size 2
+	// 4. receive pages with totalItemCount 5 and self/next/prev links
+	// 5. send NFListRetrieve request with page-number out of range and receive 404
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct network function request content
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	// http request NFListRetrieve on empty NRF
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	var response UriList
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, response.TotalItemCount)
+	assert.Empty(t, response.Links.Item)
+	// register network functions
+	var nfInstanceIds []string
+	for _, nfType := range []string{"AMF", "AMF", "AMF", "SMF", "SMF"} {
+		nfInstanceId := uuid.New().String()
+		profile := NFProfile{
+			NFInstanceId: nfInstanceId,
+			NFType:       nfType,
+			NFStatus:     "REGISTERED",
+		}
+		body, err := json.Marshal(profile)
+		if err != nil {
+			t.Errorf("Error marshalling profile: %v", err)
+		}
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		nfInstanceIds = append(nfInstanceIds, nfInstanceId)
+	}
+	// http request NFListRetrieve page by page
+	for pageNumber, expected := range [][]string{nfInstanceIds[0:2], nfInstanceIds[2:4], nfInstanceIds[4:5]} {
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodGet, url+"?page-size=2&page-number="+strconv.Itoa(pageNumber+1), nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		router.ServeHTTP(w, request)
+		response = UriList{}
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		// assert http response
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/3gppHal+json", w.Header().Get("Content-Type"))
+		assert.Equal(t, 5, response.TotalItemCount)
+		assert.Len(t, response.Links.Item, len(expected))
+		for k, v := range expected {
+			assert.Equal(t, url+"/"+v, response.Links.Item[k].Href)
+		}
+		assert.Equal(t, url+"?page-number="+strconv.Itoa(pageNumber+1)+"&page-size=2", response.Links.Self.Href)
+		if pageNumber < 2 {
+			assert.Equal(t, url+"?page-number="+strconv.Itoa(pageNumber+2)+"&page-size=2", response.Links.Next.Href)
+		} else {
+			assert.Nil(t, response.Links.Next)
+		}
+		if pageNumber > 0 {
+			assert.Equal(t, url+"?page-number="+strconv.Itoa(pageNumber)+"&page-size=2", response.Links.Prev.Href)
+		} else {
+			assert.Nil(t, response.Links.Prev)
+		}
+	}
+	// http request NFListRetrieve with nf-type and limit
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url+"?nf-type=SMF&limit=1", nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	response = UriList{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, response.TotalItemCount)
+	assert.Len(t, response.Links.Item, 1)
+	assert.Equal(t, url+"/"+nfInstanceIds[3], response.Links.Item[0].Href)
+	// http request NFListRetrieve with page-number out of range
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url+"?page-size=2&page-number=4", nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var problemDetails ProblemDetails
+	err = json.Unmarshal(w.Body.Bytes(), &problemDetails)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, http.StatusNotFound, problemDetails.Status)
+}
+
 func BenchmarkHandleNFListRetrieve(b *testing.B) {
 	// start http test service
 	server, router := startTestServer()
@@ -2599,7 +3487,7 @@ func BenchmarkHandleNFListRetrieve(b *testing.B) {
 		assert.Equal(b, nfStatus, response.NFStatus)
 		// http request NFListRetrieve
 		w = httptest.NewRecorder()
-		request, err = http.NewRequest(http.MethodGet, url+"?nf-type=SMF&limit=1&page-number=1&page-size=1", nil)
+		request, err = http.NewRequest(http.MethodGet, url+"?nf-type=SMF&limit=1&page-number="+strconv.Itoa(i+1)+"&page-size=1", nil)
 		if err != nil {
 			b.Errorf("Error creating request: %v", err)
 		}
@@ -2613,8 +3501,8 @@ func BenchmarkHandleNFListRetrieve(b *testing.B) {
 		// assert http response
 		assert.Equal(b, http.StatusOK, w.Code)
 		assert.Equal(b, "application/3gppHal+json", w.Header().Get("Content-Type"))
-		assert.Equal(b, url+"/"+nfInstanceId, responseNew.Links[0])
-		assert.Equal(b, 1, responseNew.TotalItemCount)
+		assert.Equal(b, url+"/"+nfInstanceId, responseNew.Links.Item[0].Href)
+		assert.Equal(b, i+1, responseNew.TotalItemCount)
 	}
 }
 
@@ -2675,8 +3563,8 @@ func BenchmarkHandleNFListRetrieveParallel(b *testing.B) {
 			// assert http response
 			assert.Equal(b, http.StatusOK, w.Code)
 			assert.Equal(b, "application/3gppHal+json", w.Header().Get("Content-Type"))
-			assert.Equal(b, url+"/"+nfInstanceId, responseNew.Links[0])
-			assert.Equal(b, 1, responseNew.TotalItemCount)
+			assert.Len(b, responseNew.Links.Item, 1)
+			assert.GreaterOrEqual(b, responseNew.TotalItemCount, 1)
 		}
 	})
 }
@@ -2744,7 +3632,7 @@ func FuzzHandleNFListRetrieve(f *testing.F) {
 		// assert http response
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Equal(t, "application/3gppHal+json", w.Header().Get("Content-Type"))
-		assert.Equal(t, url+"/"+nfInstanceId, responseNew.Links[0])
+		assert.Equal(t, url+"/"+nfInstanceId, responseNew.Links.Item[0].Href)
 		assert.Equal(t, 1, responseNew.TotalItemCount)
 	})
 }
@@ -0,0 +1,365 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/logs"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const maxNotificationBackoff = 30 * time.Second
+
+type NotificationEngine struct {
+	client         *http.Client
+	queueSize      int
+	maxRetries     int
+	retryInterval  time.Duration
+	deadLetterSize int
+	queues         map[string]*notificationQueue
+	// queues scheduled on the worker pool, never blocks enqueue
+	ready       []*notificationQueue
+	wakeup      *sync.Cond
+	deadLetters []DeadLetter
+	counters    notificationCounters
+	mutex       sync.Mutex
+}
+
+type NotificationCounters struct {
+	Enqueued       int64 `json:"enqueued" yaml:"enqueued"`
+	Delivered      int64 `json:"delivered" yaml:"delivered"`
+	Retried        int64 `json:"retried" yaml:"retried"`
+	FailedAttempts int64 `json:"failedAttempts" yaml:"failedAttempts"`
+	Dropped        int64 `json:"dropped" yaml:"dropped"`
+	DeadLettered   int64 `json:"deadLettered" yaml:"deadLettered"`
+}
+
+type DeadLetter struct {
+	SubscriptionId          string           `json:"subscriptionId" yaml:"subscriptionId"`
+	NFStatusNotificationUri string           `json:"nfStatusNotificationUri" yaml:"nfStatusNotificationUri"`
+	NotificationData        NotificationData `json:"notificationData" yaml:"notificationData"`
+	Attempts                int              `json:"attempts" yaml:"attempts"`
+	LastError               string           `json:"lastError" yaml:"lastError"`
+	Time                    time.Time        `json:"time" yaml:"time"`
+}
+
+type notificationCounters struct {
+	enqueued       atomic.Int64
+	delivered      atomic.Int64
+	retried        atomic.Int64
+	failedAttempts atomic.Int64
+	dropped        atomic.Int64
+	deadLettered   atomic.Int64
+}
+
+type notificationQueue struct {
+	subscriptionId string
+	items          []notificationItem
+	active         bool
+	// subscription removed, pending notifications and retries are dropped
+	cancelled bool
+}
+
+type notificationItem struct {
+	uri      string
+	data     NotificationData
+	attempts int
+	backoff  time.Duration
+}
+
+func NewNotificationEngine(settings NotificationSettings) *NotificationEngine {
+	// apply default settings
+	if settings.Workers <= 0 {
+		settings.Workers = 8
+	}
+	if settings.QueueSize <= 0 {
+		settings.QueueSize = 1024
+	}
+	if settings.MaxRetries < 0 {
+		settings.MaxRetries = 0
+	}
+	if settings.RetryInterval <= 0 {
+		settings.RetryInterval = 500
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = 3000
+	}
+	if settings.DeadLetterSize <= 0 {
+		settings.DeadLetterSize = 1024
+	}
+	engine := &NotificationEngine{
+		client:         &http.Client{Timeout: time.Duration(settings.Timeout) * time.Millisecond},
+		queueSize:      settings.QueueSize,
+		maxRetries:     settings.MaxRetries,
+		retryInterval:  time.Duration(settings.RetryInterval) * time.Millisecond,
+		deadLetterSize: settings.DeadLetterSize,
+		queues:         make(map[string]*notificationQueue),
+	}
+	engine.wakeup = sync.NewCond(&engine.mutex)
+	// start bounded worker pool
+	for i := 0; i < settings.Workers; i++ {
+		go engine.work()
+	}
+	return engine
+}
+
+func (engine *NotificationEngine) Enqueue(subscriptionId string, uri string, data NotificationData) {
+	engine.counters.enqueued.Add(1)
+	engine.mutex.Lock()
+	queue, exists := engine.queues[subscriptionId]
+	if !exists {
+		queue = &notificationQueue{subscriptionId: subscriptionId}
+		engine.queues[subscriptionId] = queue
+	}
+	// drop notification when subscription queue overflow
+	if len(queue.items) >= engine.queueSize {
+		engine.mutex.Unlock()
+		engine.counters.dropped.Add(1)
+		engine.deadLetter(subscriptionId, notificationItem{uri: uri, data: data}, 0, errors.New("notification queue overflow"))
+		return
+	}
+	queue.items = append(queue.items, notificationItem{uri: uri, data: data, backoff: engine.retryInterval})
+	// schedule queue on worker pool when idle
+	if !queue.active {
+		queue.active = true
+		engine.ready = append(engine.ready, queue)
+		engine.wakeup.Signal()
+	}
+	engine.mutex.Unlock()
+}
+
+func (engine *NotificationEngine) Cancel(subscriptionId string) {
+	engine.mutex.Lock()
+	queue, exists := engine.queues[subscriptionId]
+	if !exists {
+		engine.mutex.Unlock()
+		return
+	}
+	// the worker or retry timer holding the queue finds it empty
+	dropped := len(queue.items)
+	queue.items = nil
+	queue.cancelled = true
+	delete(engine.queues, subscriptionId)
+	engine.mutex.Unlock()
+	engine.counters.dropped.Add(int64(dropped))
+	L.Debug("NFStatusNotify cancelled:", subscriptionId, "dropped:", dropped)
+}
+
+func (engine *NotificationEngine) schedule(queue *notificationQueue) {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	engine.ready = append(engine.ready, queue)
+	engine.wakeup.Signal()
+}
+
+func (engine *NotificationEngine) Counters() NotificationCounters {
+	return NotificationCounters{
+		Enqueued:       engine.counters.enqueued.Load(),
+		Delivered:      engine.counters.delivered.Load(),
+		Retried:        engine.counters.retried.Load(),
+		FailedAttempts: engine.counters.failedAttempts.Load(),
+		Dropped:        engine.counters.dropped.Load(),
+		DeadLettered:   engine.counters.deadLettered.Load(),
+	}
+}
+
+func (engine *NotificationEngine) DeadLetters() []DeadLetter {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	return append([]DeadLetter(nil), engine.deadLetters...)
+}
+
+func (engine *NotificationEngine) work() {
+	for {
+		engine.mutex.Lock()
+		for len(engine.ready) == 0 {
+			engine.wakeup.Wait()
+		}
+		queue := engine.ready[0]
+		engine.ready = engine.ready[1:]
+		engine.mutex.Unlock()
+		engine.drain(queue)
+	}
+}
+
+func (engine *NotificationEngine) drain(queue *notificationQueue) {
+	// drain subscription queue in order, one notification at a time
+	for {
+		engine.mutex.Lock()
+		if len(queue.items) == 0 {
+			queue.active = false
+			if engine.queues[queue.subscriptionId] == queue {
+				delete(engine.queues, queue.subscriptionId)
+			}
+			engine.mutex.Unlock()
+			return
+		}
+		item := queue.items[0]
+		queue.items = queue.items[1:]
+		engine.mutex.Unlock()
+		if engine.deliver(queue.subscriptionId, &item) {
+			continue
+		}
+		// retry keeps its place in the queue, the worker is freed during exponential backoff
+		delay := item.backoff
+		item.backoff *= 2
+		if item.backoff > maxNotificationBackoff {
+			item.backoff = maxNotificationBackoff
+		}
+		engine.mutex.Lock()
+		if queue.cancelled {
+			queue.active = false
+			engine.mutex.Unlock()
+			engine.counters.dropped.Add(1)
+			return
+		}
+		queue.items = append([]notificationItem{item}, queue.items...)
+		engine.mutex.Unlock()
+		time.AfterFunc(delay, func() {
+			engine.schedule(queue)
+		})
+		return
+	}
+}
+
+func (engine *NotificationEngine) deliver(subscriptionId string, item *notificationItem) (done bool) {
+	body, err := json.Marshal(item.data)
+	if err != nil {
+		engine.deadLetter(subscriptionId, *item, item.attempts, err)
+		return true
+	}
+	item.attempts++
+	err = engine.post(item.uri, body)
+	if err == nil {
+		engine.counters.delivered.Add(1)
+		L.Debug("NFStatusNotify delivered:", subscriptionId, item.data.Event, item.data.NFInstanceUri)
+		return true
+	}
+	engine.counters.failedAttempts.Add(1)
+	L.Warning("NFStatusNotify attempt", item.attempts, "failed:", subscriptionId, err)
+	if item.attempts > engine.maxRetries {
+		engine.deadLetter(subscriptionId, *item, item.attempts, err)
+		return true
+	}
+	engine.counters.retried.Add(1)
+	return false
+}
+
+func (engine *NotificationEngine) post(uri string, body []byte) (err error) {
+	response, err := engine.client.Post(uri, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("notification callback responded %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (engine *NotificationEngine) deadLetter(subscriptionId string, item notificationItem, attempts int, err error) {
+	engine.counters.deadLettered.Add(1)
+	L.Error("NFStatusNotify dead-lettered:", subscriptionId, item.data.Event, item.data.NFInstanceUri, err)
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	// keep the latest dead letters only
+	if len(engine.deadLetters) >= engine.deadLetterSize {
+		engine.deadLetters = engine.deadLetters[1:]
+	}
+	engine.deadLetters = append(engine.deadLetters, DeadLetter{
+		SubscriptionId:          subscriptionId,
+		NFStatusNotificationUri: item.uri,
+		NotificationData:        item.data,
+		Attempts:                attempts,
+		LastError:               err.Error(),
+		Time:                    time.Now(),
+	})
+}
+
+func (nrf *NRF) notifyNFStatus(event string, nfInstanceUri string, profile NFProfile) {
+	if nrf.notifier == nil {
+		return
+	}
+	// match subscriptions and purge expired ones, enqueue never blocks so unsubscribe cancels every queued notification
+	now := time.Now()
+	nrf.mutex.Lock()
+	defer nrf.mutex.Unlock()
+	for k, v := range nrf.subscriptions {
+		if v.ValidityTime != nil && !v.ValidityTime.After(now) {
+			L.Info("NFStatusSubscription expired:", k)
+			delete(nrf.subscriptions, k)
+			nrf.notifier.Cancel(k)
+			continue
+		}
+		if !matchSubscription(&v, event, &profile) {
+			continue
+		}
+		// subscribers are notified of the profiles and services they may access
+		requester := nrf.subscriberRequester(&v)
+		if !authorizeNFProfile(&requester, &profile) {
+			L.Debug("NFStatusSubscription not allowed to access NFInstance:", k, profile.NFInstanceId)
+			continue
+		}
+		// assemble notification data
+		data := NotificationData{
+			Event:         event,
+			NFInstanceUri: nfInstanceUri,
+		}
+		if event != "NF_DEREGISTERED" {
+			filtered := profile
+			filterNFServices(&requester, &filtered)
+			data.NFProfile = &filtered
+		}
+		nrf.notifier.Enqueue(v.SubscriptionId, v.NFStatusNotificationUri, data)
+	}
+}
+
+func (nrf *NRF) subscriberRequester(subscription *SubscriptionData) (requester nfRequester) {
+	// caller holds nrf.mutex, reqNfInstanceId is bound to the access token subject when authorization is required
+	requester = nrf.registeredRequester(subscription.ReqNFInstanceId, NRFConfigure.OAuth2Settings.AuthorizationRequired)
+	if requester.NFType == "" {
+		requester.NFType = subscription.ReqNFType
+	}
+	return requester
+}
+
+func matchSubscription(subscription *SubscriptionData, event string, profile *NFProfile) bool {
+	// match requested notification events
+	if len(subscription.ReqNotifEvents) != 0 {
+		matched := false
+		for _, v := range subscription.ReqNotifEvents {
+			if v == event {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	// match subscription condition
+	if subscription.SubscrCond == nil {
+		return true
+	}
+	switch {
+	case subscription.SubscrCond.NFInstanceId != "":
+		return subscription.SubscrCond.NFInstanceId == profile.NFInstanceId
+	case subscription.SubscrCond.NFType != "":
+		return subscription.SubscrCond.NFType == profile.NFType
+	case subscription.SubscrCond.ServiceName != "":
+		for _, v := range profile.NFServices {
+			if v.ServiceName == subscription.SubscrCond.ServiceName {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/logs"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startTestNotificationServer(status int) (*httptest.Server, *[]NotificationData, *sync.Mutex) {
+	var received []NotificationData
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data NotificationData
+		_ = json.NewDecoder(r.Body).Decode(&data)
+		mutex.Lock()
+		received = append(received, data)
+		mutex.Unlock()
+		w.WriteHeader(status)
+	}))
+	return server, &received, &mutex
+}
+
+func TestNotificationEngineDelivery(t *testing.T) {
+	// start notification callback service
+	callback, received, mutex := startTestNotificationServer(http.StatusNoContent)
+	defer callback.Close()
+	// enqueue notifications of the same subscription
+	engine := NewNotificationEngine(NotificationSettings{Workers: 4, RetryInterval: 1})
+	subscriptionId := uuid.New().String()
+	for i := 0; i < 10; i++ {
+		engine.Enqueue(subscriptionId, callback.URL, NotificationData{
+			Event:         "NF_PROFILE_CHANGED",
+			NFInstanceUri: callback.URL + "/" + string(rune('a'+i)),
+		})
+	}
+	// wait for delivery
+	assert.Eventually(t, func() bool {
+		return engine.Counters().Delivered == 10
+	}, 5*time.Second, 10*time.Millisecond)
+	// assert notifications delivered in order
+	mutex.Lock()
+	defer mutex.Unlock()
+	for i, v := range *received {
+		assert.Equal(t, callback.URL+"/"+string(rune('a'+i)), v.NFInstanceUri)
+	}
+	assert.Equal(t, int64(10), engine.Counters().Enqueued)
+	assert.Equal(t, int64(0), engine.Counters().DeadLettered)
+}
+
+func TestNotificationEngineDeadLetter(t *testing.T) {
+	// start notification callback service always failed
+	callback, received, mutex := startTestNotificationServer(http.StatusInternalServerError)
+	defer callback.Close()
+	// enqueue notification
+	engine := NewNotificationEngine(NotificationSettings{Workers: 1, MaxRetries: 2, RetryInterval: 1})
+	subscriptionId := uuid.New().String()
+	engine.Enqueue(subscriptionId, callback.URL, NotificationData{Event: "NF_DEREGISTERED", NFInstanceUri: callback.URL})
+	// wait for dead letter
+	assert.Eventually(t, func() bool {
+		return engine.Counters().DeadLettered == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// assert dead letter recorded after retries
+	deadLetters := engine.DeadLetters()
+	assert.Equal(t, 1, len(deadLetters))
+	assert.Equal(t, subscriptionId, deadLetters[0].SubscriptionId)
+	assert.Equal(t, 3, deadLetters[0].Attempts)
+	assert.Equal(t, int64(2), engine.Counters().Retried)
+	assert.Equal(t, int64(3), engine.Counters().FailedAttempts)
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 3, len(*received))
+}
+
+func TestNotificationEngineRetryBackoff(t *testing.T) {
+	// start notification callback services failed and healthy
+	failed, _, _ := startTestNotificationServer(http.StatusInternalServerError)
+	defer failed.Close()
+	healthy, received, mutex := startTestNotificationServer(http.StatusNoContent)
+	defer healthy.Close()
+	// single worker shall not be held by the backoff of the failed subscription
+	engine := NewNotificationEngine(NotificationSettings{Workers: 1, MaxRetries: 3, RetryInterval: 1000})
+	engine.Enqueue(uuid.New().String(), failed.URL, NotificationData{Event: "NF_DEREGISTERED", NFInstanceUri: failed.URL})
+	engine.Enqueue(uuid.New().String(), healthy.URL, NotificationData{Event: "NF_DEREGISTERED", NFInstanceUri: healthy.URL})
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(*received) == 1
+	}, 500*time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, int64(1), engine.Counters().Retried)
+}
+
+func TestNotificationEngineCancel(t *testing.T) {
+	// cancellation is logged
+	err := InitLog()
+	if err != nil {
+		t.Fatalf("Error initializing logger: %v", err)
+	}
+	// start notification callback service always failed
+	callback, received, mutex := startTestNotificationServer(http.StatusInternalServerError)
+	defer callback.Close()
+	// enqueue notifications retried for long
+	engine := NewNotificationEngine(NotificationSettings{Workers: 1, MaxRetries: 100, RetryInterval: 50})
+	subscriptionId := uuid.New().String()
+	for i := 0; i < 3; i++ {
+		engine.Enqueue(subscriptionId, callback.URL, NotificationData{Event: "NF_DEREGISTERED", NFInstanceUri: callback.URL})
+	}
+	assert.Eventually(t, func() bool {
+		return engine.Counters().FailedAttempts >= 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// unsubscribed subscription is neither delivered nor retried
+	engine.Cancel(subscriptionId)
+	mutex.Lock()
+	attempts := len(*received)
+	mutex.Unlock()
+	time.Sleep(300 * time.Millisecond)
+	mutex.Lock()
+	assert.LessOrEqual(t, len(*received), attempts+1)
+	mutex.Unlock()
+	assert.Equal(t, int64(3), engine.Counters().Dropped)
+	assert.Equal(t, int64(0), engine.Counters().DeadLettered)
+	assert.Empty(t, engine.DeadLetters())
+	// subscription queue is released
+	engine.mutex.Lock()
+	assert.NotContains(t, engine.queues, subscriptionId)
+	engine.mutex.Unlock()
+}
+
+func TestHandleNFStatusNotify(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// start notification callback service
+	callback, received, mutex := startTestNotificationServer(http.StatusNoContent)
+	defer callback.Close()
+	// http request NFStatusSubscribe
+	subscription := SubscriptionData{
+		NFStatusNotificationUri: callback.URL,
+		SubscrCond:              &SubscrCond{NFType: "SMF"},
+	}
+	body, err := json.Marshal(subscription)
+	if err != nil {
+		t.Errorf("Error marshalling subscription: %v", err)
+	}
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/nnrf-nfm/v1/subscriptions", bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	// http request NFRegister and NFDeregister
+	url := server.URL + "/nnrf-nfm/v1/nf-instances"
+	for _, nfType := range []string{"AMF", "SMF"} {
+		nfInstanceId := uuid.New().String()
+		profile := NFProfile{
+			NFInstanceId: nfInstanceId,
+			NFType:       nfType,
+			NFStatus:     "REGISTERED",
+		}
+		body, err = json.Marshal(profile)
+		if err != nil {
+			t.Errorf("Error marshalling profile: %v", err)
+		}
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodPut, url+"/"+nfInstanceId, bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodDelete, url+"/"+nfInstanceId, nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	}
+	// assert only SMF notifications received in order
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(*received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, "NF_REGISTERED", (*received)[0].Event)
+	assert.Equal(t, "SMF", (*received)[0].NFProfile.NFType)
+	assert.Equal(t, "NF_DEREGISTERED", (*received)[1].Event)
+	assert.Nil(t, (*received)[1].NFProfile)
+}
+
+func TestHandleNFStatusNotifyWithAllowedRequesters(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// http request NFStatusSubscribe from an AMF and a PCF
+	callbacks := make(map[string]*[]NotificationData)
+	mutexes := make(map[string]*sync.Mutex)
+	for _, nfType := range []string{"AMF", "PCF"} {
+		callback, received, mutex := startTestNotificationServer(http.StatusNoContent)
+		defer callback.Close()
+		callbacks[nfType], mutexes[nfType] = received, mutex
+		subscription := SubscriptionData{
+			NFStatusNotificationUri: callback.URL,
+			SubscrCond:              &SubscrCond{NFType: "SMF"},
+			ReqNFType:               nfType,
+		}
+		body, err := json.Marshal(subscription)
+		if err != nil {
+			t.Errorf("Error marshalling subscription: %v", err)
+		}
+		w := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, server.URL+"/nnrf-nfm/v1/subscriptions", bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+	// register an SMF allowing AMF and PCF with a service allowing AMF only, then an SMF allowing AMF only
+	service1 := testNFService("1", "nsmf-pdusession")
+	service1.AllowedNfTypes = []string{"AMF"}
+	service2 := testNFService("2", "nsmf-event-exposure")
+	smf1 := NFProfile{
+		NFInstanceId:   uuid.New().String(),
+		NFType:         "SMF",
+		NFStatus:       "REGISTERED",
+		AllowedNfTypes: []string{"AMF", "PCF"},
+		NFServices:     []NFService{service1, service2},
+	}
+	smf2 := NFProfile{
+		NFInstanceId:   uuid.New().String(),
+		NFType:         "SMF",
+		NFStatus:       "REGISTERED",
+		AllowedNfTypes: []string{"AMF"},
+	}
+	registerTestNFProfile(t, router, smf1)
+	registerTestNFProfile(t, router, smf2)
+	// assert subscribers are notified of the profiles and services they may access only
+	for nfType, expected := range map[string]int{"AMF": 2, "PCF": 1} {
+		received, mutex := callbacks[nfType], mutexes[nfType]
+		assert.Eventually(t, func() bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return len(*received) == expected
+		}, 5*time.Second, 10*time.Millisecond, nfType)
+	}
+	time.Sleep(100 * time.Millisecond)
+	mutexes["PCF"].Lock()
+	defer mutexes["PCF"].Unlock()
+	assert.Len(t, *callbacks["PCF"], 1)
+	assert.Equal(t, smf1.NFInstanceId, (*callbacks["PCF"])[0].NFProfile.NFInstanceId)
+	assert.Equal(t, []NFService{service2}, (*callbacks["PCF"])[0].NFProfile.NFServices)
+	mutexes["AMF"].Lock()
+	defer mutexes["AMF"].Unlock()
+	assert.Len(t, (*callbacks["AMF"])[0].NFProfile.NFServices, 2)
+}
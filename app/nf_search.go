@@ -0,0 +1,187 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/logs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bounds of stored searches when not configured
+const (
+	defaultMaxStoredSearches   = 1024
+	defaultMaxStoredSearchSize = 64 << 20
+)
+
+type storedSearch struct {
+	result   SearchResult
+	complete []NFProfile
+	expiry   time.Time
+	// query and requester the search was stored for, and its JSON size
+	key       string
+	requester string
+	size      int
+}
+
+func (nrf *NRF) HandleSearchRetrieve(context *gin.Context) {
+	// record context in logs
+	L.Info("SearchRetrieve request:", context.Request)
+	nrf.retrieveSearch(context, false)
+}
+
+func (nrf *NRF) HandleSearchCompleteRetrieve(context *gin.Context) {
+	// record context in logs
+	L.Info("SearchCompleteRetrieve request:", context.Request)
+	nrf.retrieveSearch(context, true)
+}
+
+func (nrf *NRF) retrieveSearch(context *gin.Context, complete bool) {
+	// extract searchId from request uri
+	searchId := strings.ToLower(context.Param("searchId"))
+	// search stored result in database
+	search, exists := func(searchId string) (search storedSearch, exists bool) {
+		nrf.searchesMutex.Lock()
+		defer nrf.searchesMutex.Unlock()
+		search, exists = nrf.searches[searchId]
+		if exists && !search.expiry.After(time.Now()) {
+			L.Info("Stored search expired:", searchId)
+			nrf.deleteSearch(searchId)
+			return search, false
+		}
+		// the result is filtered for its requester, others can not tell it exists
+		if exists && search.requester != context.GetString("clientID") {
+			L.Debug("Stored search requested by another requester:", searchId, context.GetString("clientID"))
+			return search, false
+		}
+		return search, exists
+	}(searchId)
+	// return 404 Not Found
+	if !exists {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Not Found"
+		problemDetails.Status = http.StatusNotFound
+		problemDetails.Detail = errors.New("SearchId not found").Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusNotFound, problemDetails)
+		L.Error("SearchRetrieve request search not found:", searchId)
+		return
+	}
+	var response StoredSearchResult
+	response.NFInstances = search.result.NFInstances
+	if complete {
+		response.NFInstances = search.complete
+	}
+	// return success response, cacheable until the search expires
+	context.Header("Content-Type", "application/json")
+	context.Header("Cache-Control", fmt.Sprintf("max-age=%d", int(time.Until(search.expiry).Seconds())))
+	context.JSON(http.StatusOK, response)
+	return
+}
+
+func formSearchKey(context *gin.Context) (key string) {
+	// same query parameters in any order by the same requester share one stored search
+	query := context.Request.URL.Query()
+	for _, v := range query {
+		sort.Strings(v)
+	}
+	return context.GetString("clientID") + "?" + query.Encode()
+}
+
+func (nrf *NRF) storeSearch(key string, requester string, searchResult *SearchResult, complete []NFProfile) (searchId string) {
+	// caller holds nrf.mutex, so no deregistration evicts the instances before they are stored
+	if searchResult.ValidityPeriod <= 0 {
+		return ""
+	}
+	maxSearches, maxSize := NRFConfigure.MaxStoredSearches, NRFConfigure.MaxStoredSearchSize
+	if maxSearches <= 0 {
+		maxSearches = defaultMaxStoredSearches
+	}
+	if maxSize <= 0 {
+		maxSize = defaultMaxStoredSearchSize
+	}
+	body, _ := json.Marshal(complete)
+	if len(body) > maxSize {
+		L.Warning("Search result not stored, size exceeds maxStoredSearchSize:", len(body))
+		return ""
+	}
+	nrf.searchesMutex.Lock()
+	defer nrf.searchesMutex.Unlock()
+	// a repeated search replaces the stored one under the same searchId
+	searchId, exists := nrf.searchKeys[key]
+	if exists {
+		nrf.deleteSearch(searchId)
+	} else {
+		searchId = uuid.New().String()
+	}
+	// drop the earliest expiring searches beyond the bounds
+	for len(nrf.searches) >= maxSearches || nrf.searchesSize+len(body) > maxSize {
+		var earliest string
+		for k, v := range nrf.searches {
+			if earliest == "" || v.expiry.Before(nrf.searches[earliest].expiry) {
+				earliest = k
+			}
+		}
+		L.Debug("Stored search dropped:", earliest)
+		nrf.deleteSearch(earliest)
+	}
+	searchResult.SearchId = searchId
+	nrf.searches[searchId] = storedSearch{
+		result:    *searchResult,
+		complete:  complete,
+		expiry:    time.Now().Add(time.Duration(searchResult.ValidityPeriod) * time.Second),
+		key:       key,
+		requester: requester,
+		size:      len(body),
+	}
+	nrf.searchKeys[key] = searchId
+	nrf.searchesSize += len(body)
+	return searchId
+}
+
+func (nrf *NRF) deleteSearch(searchId string) {
+	// caller holds nrf.searchesMutex
+	search, exists := nrf.searches[searchId]
+	if !exists {
+		return
+	}
+	delete(nrf.searches, searchId)
+	if nrf.searchKeys[search.key] == searchId {
+		delete(nrf.searchKeys, search.key)
+	}
+	nrf.searchesSize -= search.size
+}
+
+func (nrf *NRF) evictSearches(nfInstanceId string) {
+	// drop stored searches referencing a deregistered NFInstance
+	nrf.searchesMutex.Lock()
+	defer nrf.searchesMutex.Unlock()
+	for k, v := range nrf.searches {
+		for _, j := range v.complete {
+			if j.NFInstanceId == nfInstanceId {
+				L.Debug("Stored search evicted:", k, "NFInstance deregistered:", nfInstanceId)
+				nrf.deleteSearch(k)
+				break
+			}
+		}
+	}
+}
+
+func (nrf *NRF) purgeSearches(now time.Time) {
+	// drop stored searches beyond their validity period
+	nrf.searchesMutex.Lock()
+	defer nrf.searchesMutex.Unlock()
+	for k, v := range nrf.searches {
+		if !v.expiry.After(now) {
+			L.Debug("Stored search expired:", k)
+			nrf.deleteSearch(k)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	. "nrf/conf"
+	. "nrf/data"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleSearchRetrieve(t *testing.T) {
+	/*-----------------------------------------------------------------------
+	// Test Case: TestHandleSearchRetrieve
+	// Test Purpose: Test NFDiscover results are stored and retrievable by searchId
+	// Test Steps:
+	// 1. register an SMF and send NFDiscover request
+	// 2. receive 200 OK with searchId and Location of the stored search
+	// 3. retrieve the stored search and its complete result
+	// 4. deregister the SMF and receive 404 Not Found for the evicted searches
+	-------------------------------------------------------------------------*/
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// register network functions
+	smf := NFProfile{
+		NFInstanceId: uuid.New().String(),
+		NFType:       "SMF",
+		NFStatus:     "REGISTERED",
+	}
+	registerTestNFProfile(t, router, smf)
+	// http request NFDiscover
+	w, searchResult := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=AMF")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, searchResult.SearchId)
+	assert.True(t, strings.HasSuffix(w.Header().Get("Location"), "/nnrf-disc/v1/searches/"+searchResult.SearchId))
+	// same query in another order shares the stored search, another requester does not
+	_, repeated := discoverTestNFInstances(t, router, "requester-nf-type=AMF&target-nf-type=SMF")
+	assert.Equal(t, searchResult.SearchId, repeated.SearchId)
+	_, other := discoverTestNFInstances(t, router, "target-nf-type=SMF&requester-nf-type=PCF")
+	assert.NotEqual(t, searchResult.SearchId, other.SearchId)
+	// http request SearchRetrieve and SearchCompleteRetrieve
+	for _, uri := range []string{
+		"/nnrf-disc/v1/searches/" + searchResult.SearchId,
+		"/nnrf-disc/v1/searches/" + searchResult.SearchId + "/complete",
+	} {
+		w = httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, uri, nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusOK, w.Code, uri)
+		var response StoredSearchResult
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Errorf("Error unmarshalling response: %v", err)
+		}
+		assert.Equal(t, searchResult.NFInstances, response.NFInstances, uri)
+		assert.Contains(t, w.Header().Get("Cache-Control"), "max-age=")
+	}
+	// http request NFDeregister and SearchRetrieve again
+	w = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodDelete, "/nnrf-nfm/v1/nf-instances/"+smf.NFInstanceId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	for _, searchId := range []string{searchResult.SearchId, other.SearchId, uuid.New().String()} {
+		w = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodGet, "/nnrf-disc/v1/searches/"+searchId, nil)
+		if err != nil {
+			t.Errorf("Error creating request: %v", err)
+		}
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestPurgeSearches(t *testing.T) {
+	// initialize NRF Service
+	nrf := New()
+	err := nrf.Init()
+	if err != nil {
+		t.Fatal("Error initialize NRF:", err)
+	}
+	// store search results with different validity periods
+	short := nrf.storeSearch(uuid.New().String(), "", &SearchResult{ValidityPeriod: 10}, nil)
+	long := nrf.storeSearch(uuid.New().String(), "", &SearchResult{ValidityPeriod: 100}, nil)
+	assert.Empty(t, nrf.storeSearch(uuid.New().String(), "", &SearchResult{ValidityPeriod: 0}, nil))
+	// validity period of the first search expired
+	nrf.purgeSearches(time.Now().Add(50 * time.Second))
+	assert.NotContains(t, nrf.searches, short)
+	assert.Contains(t, nrf.searches, long)
+}
+
+func TestStoreSearchBounds(t *testing.T) {
+	// initialize NRF Service
+	nrf := New()
+	err := nrf.Init()
+	if err != nil {
+		t.Fatal("Error initialize NRF:", err)
+	}
+	defer func(maxSearches int, maxSize int) {
+		NRFConfigure.MaxStoredSearches, NRFConfigure.MaxStoredSearchSize = maxSearches, maxSize
+	}(NRFConfigure.MaxStoredSearches, NRFConfigure.MaxStoredSearchSize)
+	profiles := []NFProfile{{NFInstanceId: uuid.New().String(), NFType: "SMF", NFStatus: "REGISTERED"}}
+	body, _ := json.Marshal(profiles)
+	// the earliest expiring search is dropped beyond maxStoredSearches
+	NRFConfigure.MaxStoredSearches, NRFConfigure.MaxStoredSearchSize = 2, 100*len(body)
+	first := nrf.storeSearch("first", "", &SearchResult{ValidityPeriod: 10}, profiles)
+	second := nrf.storeSearch("second", "", &SearchResult{ValidityPeriod: 100}, profiles)
+	third := nrf.storeSearch("third", "", &SearchResult{ValidityPeriod: 100}, profiles)
+	assert.NotContains(t, nrf.searches, first)
+	assert.Contains(t, nrf.searches, second)
+	assert.Contains(t, nrf.searches, third)
+	// a repeated search replaces the stored one
+	assert.Equal(t, second, nrf.storeSearch("second", "", &SearchResult{ValidityPeriod: 100}, profiles))
+	assert.Len(t, nrf.searches, 2)
+	assert.Equal(t, 2*len(body), nrf.searchesSize)
+	// searches beyond maxStoredSearchSize are dropped, or not stored at all
+	NRFConfigure.MaxStoredSearches, NRFConfigure.MaxStoredSearchSize = 10, 2*len(body)
+	fourth := nrf.storeSearch("fourth", "", &SearchResult{ValidityPeriod: 100}, profiles)
+	assert.Len(t, nrf.searches, 2)
+	assert.Contains(t, nrf.searches, fourth)
+	assert.Empty(t, nrf.storeSearch("fifth", "", &SearchResult{ValidityPeriod: 100}, append(profiles, profiles[0], profiles[0])))
+	nrf.evictSearches(profiles[0].NFInstanceId)
+	assert.Empty(t, nrf.searches)
+	assert.Empty(t, nrf.searchKeys)
+	assert.Equal(t, 0, nrf.searchesSize)
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/logs"
+	"strings"
+)
+
+func (nrf *NRF) HandleNFStatusSubscribe(context *gin.Context) {
+	var request SubscriptionData
+	// record context in logs
+	L.Info("NFStatusSubscribe request:", context.Request)
+	// check request body bind json
+	L.Debug("Start bind NFStatusSubscribe request body to json:", context.Request.Body)
+	err := context.ShouldBindJSON(&request)
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFStatusSubscribe request body bind json failed:", err)
+		return
+	}
+	L.Debug("NFStatusSubscribe request body bind json success.")
+	// check request body IEs
+	b, err := checkNFStatusSubscribeIEs(&request)
+	if b == false && err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Bad Request"
+		problemDetails.Status = http.StatusBadRequest
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusBadRequest, problemDetails)
+		L.Error("NFStatusSubscribe request check failed:", err)
+		return
+	}
+	// subscriber is the subject of the verified access token when authorization is required
+	if NRFConfigure.OAuth2Settings.AuthorizationRequired {
+		clientID := context.GetString("clientID")
+		if request.ReqNFInstanceId != "" && request.ReqNFInstanceId != clientID {
+			var problemDetails ProblemDetails
+			problemDetails.Title = "Forbidden"
+			problemDetails.Status = http.StatusForbidden
+			problemDetails.Detail = errors.New("reqNfInstanceId does not match the access token subject").Error()
+			context.Header("Content-Type", "application/problem+json")
+			context.JSON(http.StatusForbidden, problemDetails)
+			L.Error("NFStatusSubscribe request forbidden:", request.ReqNFInstanceId, clientID)
+			return
+		}
+		request.ReqNFInstanceId = clientID
+	}
+	// handle request body IEs
+	response := request
+	err = handleNFStatusSubscribeIEs(&response)
+	if err != nil {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Internal Server Error"
+		problemDetails.Status = http.StatusInternalServerError
+		problemDetails.Detail = err.Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusInternalServerError, problemDetails)
+		L.Error("NFStatusSubscribe request body handle failed:", err)
+		return
+	}
+	// store subscription in NRF Service database
+	func() {
+		nrf.mutex.Lock()
+		defer nrf.mutex.Unlock()
+		nrf.subscriptions[response.SubscriptionId] = response
+	}()
+	// return success response
+	context.Header("Content-Type", "application/json")
+	context.Header("Location", formLocation(context, "nnrf-nfm", "v1", "subscriptions", response.SubscriptionId))
+	context.JSON(http.StatusCreated, response)
+	return
+}
+
+func (nrf *NRF) HandleNFStatusUnsubscribe(context *gin.Context) {
+	// record context in logs
+	L.Info("NFStatusUnsubscribe request:", context.Request)
+	// extract subscriptionId from request uri
+	subscriptionId := strings.ToLower(context.Param("subscriptionID"))
+	L.Debug("subscriptionId:", subscriptionId)
+	// search and delete subscription from database, only its subscriber unsubscribes when authorization is required
+	clientID := context.GetString("clientID")
+	exists, owned := func(subscriptionId string) (exists bool, owned bool) {
+		nrf.mutex.Lock()
+		defer nrf.mutex.Unlock()
+		subscription, exists := nrf.subscriptions[subscriptionId]
+		if !exists {
+			return false, false
+		}
+		if NRFConfigure.OAuth2Settings.AuthorizationRequired && !strings.EqualFold(subscription.ReqNFInstanceId, clientID) {
+			return true, false
+		}
+		delete(nrf.subscriptions, subscriptionId)
+		// pending notifications and retries of the subscription are dropped
+		if nrf.notifier != nil {
+			nrf.notifier.Cancel(subscriptionId)
+		}
+		return true, true
+	}(subscriptionId)
+	// return 404 Not Found
+	if !exists {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Not Found"
+		problemDetails.Status = http.StatusNotFound
+		problemDetails.Detail = errors.New("SubscriptionId not found").Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusNotFound, problemDetails)
+		L.Error("NFStatusUnsubscribe request SubscriptionId not found in database.")
+		return
+	}
+	// return 403 Forbidden
+	if !owned {
+		var problemDetails ProblemDetails
+		problemDetails.Title = "Forbidden"
+		problemDetails.Status = http.StatusForbidden
+		problemDetails.Detail = errors.New("reqNfInstanceId of the subscription does not match the access token subject").Error()
+		context.Header("Content-Type", "application/problem+json")
+		context.JSON(http.StatusForbidden, problemDetails)
+		L.Error("NFStatusUnsubscribe request forbidden:", subscriptionId, clientID)
+		return
+	}
+	// return 204 No Content
+	context.Status(http.StatusNoContent)
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	. "nrf/data"
+	"testing"
+	"time"
+)
+
+func TestHandleNFStatusSubscribe(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct subscription request content
+	url := server.URL + "/nnrf-nfm/v1/subscriptions"
+	subscription := SubscriptionData{
+		NFStatusNotificationUri: "http://127.0.0.1:8080/notify",
+		SubscrCond:              &SubscrCond{NFType: "SMF"},
+		ReqNotifEvents:          []string{"NF_REGISTERED", "NF_DEREGISTERED"},
+		ReqNFType:               "AMF",
+	}
+	body, err := json.Marshal(subscription)
+	if err != nil {
+		t.Errorf("Error marshalling subscription: %v", err)
+	}
+	// http request NFStatusSubscribe
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	var response SubscriptionData
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	// assert http response
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, response.SubscriptionId)
+	assert.Equal(t, url+"/"+response.SubscriptionId, w.Header().Get("Location"))
+	assert.Equal(t, subscription.NFStatusNotificationUri, response.NFStatusNotificationUri)
+	assert.True(t, response.ValidityTime.After(time.Now()))
+}
+
+func TestHandleNFStatusSubscribeWithInvalidSubscrCond(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct subscription request content with multiple conditions
+	url := server.URL + "/nnrf-nfm/v1/subscriptions"
+	subscription := SubscriptionData{
+		NFStatusNotificationUri: "http://127.0.0.1:8080/notify",
+		SubscrCond:              &SubscrCond{NFType: "SMF", ServiceName: "nsmf-pdusession"},
+	}
+	body, err := json.Marshal(subscription)
+	if err != nil {
+		t.Errorf("Error marshalling subscription: %v", err)
+	}
+	// http request NFStatusSubscribe
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	// assert http response
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+}
+
+func TestHandleNFStatusUnsubscribe(t *testing.T) {
+	// start http test service
+	server, router := startTestServer()
+	defer server.Close()
+	// construct subscription request content
+	url := server.URL + "/nnrf-nfm/v1/subscriptions"
+	subscription := SubscriptionData{
+		NFStatusNotificationUri: "http://127.0.0.1:8080/notify",
+	}
+	body, err := json.Marshal(subscription)
+	if err != nil {
+		t.Errorf("Error marshalling subscription: %v", err)
+	}
+	// http request NFStatusSubscribe
+	w := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, request)
+	var response SubscriptionData
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, http.StatusCreated, w.Code)
+	// http request NFStatusUnsubscribe
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodDelete, url+"/"+response.SubscriptionId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	// http request NFStatusUnsubscribe again
+	w = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodDelete, url+"/"+response.SubscriptionId, nil)
+	if err != nil {
+		t.Errorf("Error creating request: %v", err)
+	}
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+}
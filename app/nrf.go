@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"log"
 	"net/http"
 	. "nrf/conf"
@@ -13,20 +14,33 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type NRF struct {
-	instances    map[string][]NFInstance
-	repositories map[string][]SharedRepository
-	mutex        sync.RWMutex
+	instances     map[string][]NFInstance
+	repositories  map[string][]SharedRepository
+	subscriptions map[string]SubscriptionData
+	heartbeats    map[string]time.Time
+	notifier      *NotificationEngine
+	mutex         sync.RWMutex
+	// identity index updated as instances change
+	identities *identityIndex
+	// stored discovery results by searchId, searchIds by query and requester
+	searches      map[string]storedSearch
+	searchKeys    map[string]string
+	searchesSize  int
+	searchesMutex sync.Mutex
+	// OAuth2 access token signing keys
+	signingKeys *signingKeyring
+	// client assertion verification and replay cache by sub and jti
+	clientCAs       *x509.CertPool
+	assertions      map[string]time.Time
+	assertionsMutex sync.Mutex
 }
 
 type NFInstance struct {
-	NFInstanceId   string      `json:"nfInstanceId" yaml:"nfInstanceId"`
-	NFType         string      `json:"nfType" yaml:"nfType"`
-	NFStatus       string      `json:"nfStatus" yaml:"nfStatus"`
-	HeartBeatTimer int         `json:"heartBeatTimer" yaml:"heartBeatTimer"`
-	NFServices     []NFService `json:"nfServices" yaml:"nfServices"`
+	NFProfile `yaml:",inline"`
 }
 
 type SharedRepository struct {
@@ -37,8 +51,14 @@ type SharedRepository struct {
 
 func New() *NRF {
 	return &NRF{
-		instances:    make(map[string][]NFInstance),
-		repositories: make(map[string][]SharedRepository),
+		instances:     make(map[string][]NFInstance),
+		repositories:  make(map[string][]SharedRepository),
+		subscriptions: make(map[string]SubscriptionData),
+		heartbeats:    make(map[string]time.Time),
+		identities:    newIdentityIndex(),
+		searches:      make(map[string]storedSearch),
+		searchKeys:    make(map[string]string),
+		assertions:    make(map[string]time.Time),
 	}
 }
 
@@ -56,10 +76,50 @@ func (nrf *NRF) Init() (err error) {
 		return err
 	}
 	L.Info("Loading NRF Configuration Success.")
+	// NRF instance id issues access tokens, generated when not configured
+	if NRFConfigure.NRFInstanceId == "" {
+		NRFConfigure.NRFInstanceId = uuid.New().String()
+		L.Warning("NRF Instance ID not configured, generated:", NRFConfigure.NRFInstanceId)
+	}
+	nrf.signingKeys, err = loadSigningKeyring(NRFConfigure.OAuth2Settings.SigningKeys, time.Duration(accessTokenValidityTime())*time.Second)
+	if err != nil {
+		L.Error("Loading NRF OAuth2 Signing Keys failed:", err.Error())
+		return err
+	}
+	L.Info("Loading NRF OAuth2 Signing Keys Success.")
+	nrf.clientCAs, err = loadClientCAs(NRFConfigure.SBITLSSettings.CAFile)
+	if err != nil && NRFConfigure.OAuth2Settings.ClientAssertionRequired {
+		L.Error("Loading NRF OAuth2 Client CAs failed:", err.Error())
+		return err
+	}
+	if err != nil {
+		L.Warning("Loading NRF OAuth2 Client CAs failed, client assertions are rejected:", err.Error())
+		err = nil
+	}
+	if NRFConfigure.SBITLSSettings.TLSType != "mutual-tls" {
+		L.Warning("SBI TLS is not mutual-tls, access tokens are granted to requesters with client assertions only.")
+	}
+	nrf.notifier = NewNotificationEngine(NRFConfigure.NotificationSettings)
+	L.Info("Initialize NRF Notification Engine Success.")
 	L.Info("Initialize NRF Success.")
 	return err
 }
 
+func authorizationConfig() AuthorizationConfig {
+	// NF instances are modified by their own NF only
+	owners := make(map[string]string)
+	for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+		owners[method+" /nnrf-nfm/v1/nf-instances/:nfInstanceID"] = "nfInstanceID"
+	}
+	return AuthorizationConfig{
+		Issuer:     NRFConfigure.NRFInstanceId,
+		Audiences:  []string{"NRF", NRFConfigure.NRFInstanceId},
+		Scopes:     map[string]string{"nnrf-nfm": "nnrf-nfm", "nnrf-disc": "nnrf-disc"},
+		Exemptions: NRFConfigure.OAuth2Settings.AuthorizationExemptions,
+		Owners:     owners,
+	}
+}
+
 func (nrf *NRF) Start() {
 	// create default Gin Engine instance
 	router := gin.Default()
@@ -70,26 +130,35 @@ func (nrf *NRF) Start() {
 	router.Use(AcceptEncodingMiddleware())
 	router.Use(SecurityHeadersMiddleware())
 	router.Use(ETagMiddleware(defaultConfig))
-	// OAuth2 protect
-	// initialize OAuth2 public key
-	/*oauthConfig.PublicKey = &oauthConfig.PrivateKey.PublicKey
-	protected := router.Group("/nnrf-nfm/v1")
-	protected.Use(AuthorizationMiddleware())
-	{
-		protected.PUT("nf-instances/:nfInstanceID", HandleNFRegisterOrNFProfileCompleteReplacement)
-		protected.GET("nf-instances/:nfInstanceID", HandleNFProfileRetrieve)
-	}*/
+	// OAuth2 protect, NRF services require the scope of their API name
+	if NRFConfigure.OAuth2Settings.AuthorizationRequired {
+		router.Use(nrf.AuthorizationMiddleware(authorizationConfig()))
+	}
 	// API route groups
 	nfManagement := router.Group("/nnrf-nfm/v1")
 	{
 		nfManagement.GET("nf-instances", nrf.HandleNFListRetrieve)
 		nfManagement.PUT("nf-instances/:nfInstanceID", nrf.HandleNFRegisterOrNFProfileCompleteReplacement)
 		nfManagement.GET("nf-instances/:nfInstanceID", nrf.HandleNFProfileRetrieve)
+		nfManagement.PATCH("nf-instances/:nfInstanceID", nrf.HandleNFUpdate)
 		nfManagement.DELETE("nf-instances/:nfInstanceID", nrf.HandleNFDeregister)
 		nfManagement.PUT("shared-data/:sharedDataId", nrf.HandleNFRegisterOrNFSharedDataCompleteReplacement)
 		nfManagement.GET("shared-data/:sharedDataId", nrf.HandleNFSharedDataRetrieve)
 		nfManagement.DELETE("shared-data/:sharedDataId", nrf.HandleNFDeregisterSharedData)
+		nfManagement.POST("subscriptions", nrf.HandleNFStatusSubscribe)
+		nfManagement.DELETE("subscriptions/:subscriptionID", nrf.HandleNFStatusUnsubscribe)
+	}
+	nfDiscovery := router.Group("/nnrf-disc/v1")
+	{
+		nfDiscovery.GET("nf-instances", nrf.HandleNFDiscover)
+		nfDiscovery.GET("searches/:searchId", nrf.HandleSearchRetrieve)
+		nfDiscovery.GET("searches/:searchId/complete", nrf.HandleSearchCompleteRetrieve)
 	}
+	// OAuth2 authorization service
+	router.POST("/oauth2/token", nrf.HandleAccessToken)
+	router.GET("/oauth2/jwks", nrf.HandleJWKSRetrieve)
+	// supervise NF heart-beat
+	nrf.StartHeartBeatSupervisor()
 	// enable SBI TLS layer
 	var tlsConfig *tls.Config
 	tlsSettings := NRFConfigure.SBITLSSettings
@@ -0,0 +1,235 @@
+package app
+
+import (
+	. "nrf/data"
+	. "nrf/logs"
+	. "nrf/util"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// rangeIndex resolves the owners of an identity among numeric ranges kept sorted
+// by start with the running maximum of ends; lookup binary searches the last start
+// not after the identity and walks back while some end may still cover it, O(log n + k)
+// for disjoint ranges but O(n) behind a wide early range; insert and remove shift
+// and refresh the following ranges in O(n); pattern ranges can not be ordered and
+// are matched one by one in O(p)
+type rangeIndex struct {
+	ranges   []indexedRange
+	maxEnds  []string
+	patterns []indexedPattern
+	owners   map[string]bool
+}
+
+type indexedRange struct {
+	start string
+	end   string
+	owner string
+}
+
+type indexedPattern struct {
+	pattern *regexp.Regexp
+	owner   string
+}
+
+// identityIndex is updated per instance as profiles change, entries keeps the
+// identities and TAIs indexed for each instance so unchanged profiles are skipped
+type identityIndex struct {
+	supi       rangeIndex
+	gpsi       rangeIndex
+	extGroupId rangeIndex
+	// TAC ranges per PLMN, single TAIs are ranges of one TAC
+	tais      map[string]*rangeIndex
+	taiOwners map[string]bool
+	entries   map[string]indexedEntries
+}
+
+type indexedEntries struct {
+	identities nfIdentities
+	tais       []Tai
+	taiRanges  []TaiRange
+}
+
+func (index *rangeIndex) insert(identityRange IdentityRange, owner string) {
+	if index.owners == nil {
+		index.owners = make(map[string]bool)
+	}
+	index.owners[owner] = true
+	// pattern takes precedence over start and end, and shall match the whole identity
+	if identityRange.Pattern != "" {
+		pattern, err := regexp.Compile("^(?:" + identityRange.Pattern + ")$")
+		if err != nil {
+			L.Warning("IdentityRange pattern ignored:", owner, err)
+			return
+		}
+		index.patterns = append(index.patterns, indexedPattern{pattern: pattern, owner: owner})
+		return
+	}
+	// insert after the ranges starting not after start, then refresh the following maximum ends
+	i := sort.Search(len(index.ranges), func(i int) bool {
+		return CompareRangeBound(index.ranges[i].start, identityRange.Start) > 0
+	})
+	index.ranges = append(index.ranges, indexedRange{})
+	copy(index.ranges[i+1:], index.ranges[i:])
+	index.ranges[i] = indexedRange{start: identityRange.Start, end: identityRange.End, owner: owner}
+	index.maxEnds = append(index.maxEnds, "")
+	index.refresh(i)
+}
+
+func (index *rangeIndex) remove(owner string) {
+	if !index.owners[owner] {
+		return
+	}
+	delete(index.owners, owner)
+	patterns := index.patterns[:0]
+	for _, v := range index.patterns {
+		if v.owner != owner {
+			patterns = append(patterns, v)
+		}
+	}
+	index.patterns = patterns
+	// keep the order of remaining ranges, maximum ends are refreshed from the first removed one
+	from := len(index.ranges)
+	ranges := index.ranges[:0]
+	for i, v := range index.ranges {
+		if v.owner == owner {
+			if i < from {
+				from = i
+			}
+			continue
+		}
+		ranges = append(ranges, v)
+	}
+	index.ranges = ranges
+	index.maxEnds = index.maxEnds[:len(ranges)]
+	index.refresh(from)
+}
+
+func (index *rangeIndex) refresh(from int) {
+	for i := from; i < len(index.ranges); i++ {
+		index.maxEnds[i] = index.ranges[i].end
+		if i > 0 && CompareRangeBound(index.maxEnds[i-1], index.ranges[i].end) > 0 {
+			index.maxEnds[i] = index.maxEnds[i-1]
+		}
+	}
+}
+
+func (index *rangeIndex) lookup(identity string, key string) (owners map[string]bool) {
+	owners = make(map[string]bool)
+	// numeric ranges, walk back from the last start not after key while some end may still cover it
+	if key != "" {
+		i := sort.Search(len(index.ranges), func(i int) bool {
+			return CompareRangeBound(index.ranges[i].start, key) > 0
+		}) - 1
+		for ; i >= 0 && CompareRangeBound(index.maxEnds[i], key) >= 0; i-- {
+			if CompareRangeBound(index.ranges[i].end, key) >= 0 {
+				owners[index.ranges[i].owner] = true
+			}
+		}
+	}
+	// pattern ranges match the whole identity
+	for _, v := range index.patterns {
+		if !owners[v.owner] && v.pattern.MatchString(identity) {
+			owners[v.owner] = true
+		}
+	}
+	return owners
+}
+
+func newIdentityIndex() (index *identityIndex) {
+	return &identityIndex{tais: make(map[string]*rangeIndex), taiOwners: make(map[string]bool), entries: make(map[string]indexedEntries)}
+}
+
+func (index *identityIndex) update(nfInstanceId string, profile *NFProfile) (changed bool) {
+	// caller holds nrf.mutex for writing, a nil profile removes the instance
+	var entries indexedEntries
+	if profile != nil {
+		entries.identities = collectNFIdentities(profile)
+		entries.tais, entries.taiRanges = collectNFTais(profile)
+	}
+	previous, exists := index.entries[nfInstanceId]
+	if reflect.DeepEqual(previous, entries) {
+		return false
+	}
+	// drop the entries of the instance, then index the current ones
+	if exists {
+		index.supi.remove(nfInstanceId)
+		index.gpsi.remove(nfInstanceId)
+		index.extGroupId.remove(nfInstanceId)
+		for k, v := range index.tais {
+			v.remove(nfInstanceId)
+			if len(v.owners) == 0 {
+				delete(index.tais, k)
+			}
+		}
+		delete(index.taiOwners, nfInstanceId)
+		delete(index.entries, nfInstanceId)
+	}
+	if profile == nil {
+		L.Debug("Identity index removed:", nfInstanceId)
+		return true
+	}
+	for _, v := range entries.identities.supiRanges {
+		index.supi.insert(v, nfInstanceId)
+	}
+	for _, v := range entries.identities.gpsiRanges {
+		index.gpsi.insert(v, nfInstanceId)
+	}
+	for _, v := range entries.identities.extGroupIdRanges {
+		index.extGroupId.insert(v, nfInstanceId)
+	}
+	for _, v := range entries.tais {
+		index.tacs(v.PlmnId).insert(IdentityRange{Start: strings.ToUpper(v.Tac), End: strings.ToUpper(v.Tac)}, nfInstanceId)
+		index.taiOwners[nfInstanceId] = true
+	}
+	for _, v := range entries.taiRanges {
+		for _, tacRange := range v.TacRangeList {
+			index.tacs(v.PlmnId).insert(IdentityRange{Start: strings.ToUpper(tacRange.Start), End: strings.ToUpper(tacRange.End), Pattern: tacRange.Pattern}, nfInstanceId)
+		}
+		index.taiOwners[nfInstanceId] = true
+	}
+	index.entries[nfInstanceId] = entries
+	L.Debug("Identity index updated:", nfInstanceId)
+	return true
+}
+
+func (index *identityIndex) tacs(plmnId PlmnId) (tacs *rangeIndex) {
+	tacs, exists := index.tais[plmnKey(plmnId)]
+	if !exists {
+		tacs = &rangeIndex{}
+		index.tais[plmnKey(plmnId)] = tacs
+	}
+	return tacs
+}
+
+func (index *identityIndex) lookupTai(tai Tai) (owners map[string]bool) {
+	tacs, exists := index.tais[plmnKey(tai.PlmnId)]
+	if !exists {
+		return make(map[string]bool)
+	}
+	return tacs.lookup(tai.Tac, strings.ToUpper(tai.Tac))
+}
+
+func plmnKey(plmnId PlmnId) string {
+	return plmnId.Mcc + "-" + plmnId.Mnc
+}
+
+// identityKey returns the numeric part of an identity used against start and end,
+// e.g. imsi-460001234567890 or extgroupid-1234@domain; other identities only match patterns
+func identityKey(identity string, prefixes ...string) (key string) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(identity, prefix) {
+			key = strings.TrimPrefix(identity, prefix)
+			if i := strings.Index(key, "@"); i >= 0 {
+				key = key[:i]
+			}
+			if b, _ := CheckRangeBound(key); b {
+				return key
+			}
+			return ""
+		}
+	}
+	return ""
+}
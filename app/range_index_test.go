@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	. "nrf/data"
+	. "nrf/logs"
+	"testing"
+)
+
+func TestRangeIndexLookup(t *testing.T) {
+	var index rangeIndex
+	index.insert(IdentityRange{Start: "460000000000000", End: "460009999999999"}, "wide")
+	index.insert(IdentityRange{Start: "460001000000000", End: "460001000000999"}, "narrow")
+	index.insert(IdentityRange{Start: "460002000000000", End: "460002999999999"}, "other")
+	index.insert(IdentityRange{Start: "99", End: "100"}, "short")
+	index.insert(IdentityRange{Pattern: "^imsi-46003[0-9]{10}$"}, "pattern")
+	index.insert(IdentityRange{Pattern: "46000"}, "partial")
+	assert.Equal(t, map[string]bool{"wide": true, "narrow": true}, index.lookup("imsi-460001000000500", "460001000000500"))
+	assert.Equal(t, map[string]bool{"wide": true, "other": true}, index.lookup("imsi-460002000000000", "460002000000000"))
+	assert.Equal(t, map[string]bool{"pattern": true}, index.lookup("imsi-460031234567890", "460031234567890"))
+	// numeric bounds compare by value, not as plain strings
+	assert.Equal(t, map[string]bool{"short": true}, index.lookup("imsi-100", "100"))
+	assert.Empty(t, index.lookup("imsi-460100000000000", "460100000000000"))
+	assert.True(t, index.owners["pattern"])
+	// patterns match the whole identity, not a part of it
+	assert.Empty(t, index.lookup("imsi-999460001", ""))
+	assert.Equal(t, map[string]bool{"partial": true}, index.lookup("46000", ""))
+	// removed owners no longer match
+	index.remove("wide")
+	index.remove("pattern")
+	assert.Equal(t, map[string]bool{"narrow": true}, index.lookup("imsi-460001000000500", "460001000000500"))
+	assert.Empty(t, index.lookup("imsi-460031234567890", "460031234567890"))
+	assert.False(t, index.owners["wide"])
+}
+
+func TestIdentityIndexUpdate(t *testing.T) {
+	// index updates are logged
+	err := InitLog()
+	if err != nil {
+		t.Fatalf("Error initializing logger: %v", err)
+	}
+	index := newIdentityIndex()
+	profile := NFProfile{
+		NFInstanceId: "udm",
+		NFType:       "UDM",
+		UdmInfo:      &UdmInfo{SupiRanges: []SupiRange{{Start: "460000000000000", End: "460009999999999"}}},
+	}
+	assert.True(t, index.update(profile.NFInstanceId, &profile))
+	assert.Equal(t, map[string]bool{"udm": true}, index.supi.lookup("imsi-460001000000500", "460001000000500"))
+	// unchanged identities, e.g. a heart-beat, keep the index
+	profile.NFStatus = "SUSPENDED"
+	assert.False(t, index.update(profile.NFInstanceId, &profile))
+	// changed ranges replace the entries of the instance
+	profile.UdmInfo = &UdmInfo{SupiRanges: []SupiRange{{Start: "460010000000000", End: "460019999999999"}}}
+	assert.True(t, index.update(profile.NFInstanceId, &profile))
+	assert.Empty(t, index.supi.lookup("imsi-460001000000500", "460001000000500"))
+	assert.Equal(t, map[string]bool{"udm": true}, index.supi.lookup("imsi-460011000000500", "460011000000500"))
+	// deregistered instance is removed
+	assert.True(t, index.update(profile.NFInstanceId, nil))
+	assert.Empty(t, index.supi.lookup("imsi-460011000000500", "460011000000500"))
+	assert.Empty(t, index.supi.ranges)
+	assert.Empty(t, index.entries)
+}
+
+func BenchmarkRangeIndexLookup(b *testing.B) {
+	// thousands of disjoint ranges owned by hundreds of instances
+	var index rangeIndex
+	for i := 0; i < 10000; i++ {
+		index.insert(IdentityRange{Start: fmt.Sprintf("46000%05d00000", i), End: fmt.Sprintf("46000%05d99999", i)}, fmt.Sprintf("udm-%d", i%500))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		owners := index.lookup("imsi-460000500012345", "460000500012345")
+		assert.Len(b, owners, 1)
+	}
+}
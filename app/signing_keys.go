@@ -0,0 +1,166 @@
+package app
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"math/big"
+	. "nrf/conf"
+	. "nrf/data"
+	. "nrf/logs"
+	"os"
+	"sort"
+	"time"
+)
+
+// signingKeyring holds the OAuth2 signing keys ordered by activation, the latest
+// active key signs new tokens; a retired key stays published for overlap after its
+// successor activates, so tokens it signed remain verifiable until they expire
+type signingKeyring struct {
+	keys    []signingKey
+	overlap time.Duration
+}
+
+type signingKey struct {
+	kid        string
+	signer     crypto.Signer
+	method     jwt.SigningMethod
+	activeFrom time.Time
+}
+
+func loadSigningKeyring(settings []SigningKey, overlap time.Duration) (keyring *signingKeyring, err error) {
+	keyring = &signingKeyring{overlap: overlap}
+	kids := make(map[string]bool)
+	for _, v := range settings {
+		if v.KeyId == "" {
+			return nil, fmt.Errorf("signing key %s has no kid", v.KeyFile)
+		}
+		if kids[v.KeyId] {
+			return nil, fmt.Errorf("signing key kid %s is duplicated", v.KeyId)
+		}
+		kids[v.KeyId] = true
+		data, err := os.ReadFile(v.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, method, err := parseSigningKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s is invalid: %w", v.KeyId, err)
+		}
+		keyring.keys = append(keyring.keys, signingKey{kid: v.KeyId, signer: signer, method: method, activeFrom: v.ActiveFrom})
+		L.Debug("Signing key loaded:", v.KeyId, method.Alg(), v.ActiveFrom)
+	}
+	// tokens do not survive restarts without configured keys
+	if len(keyring.keys) == 0 {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys = append(keyring.keys, signingKey{kid: uuid.New().String(), signer: privateKey, method: jwt.SigningMethodRS256})
+		L.Warning("Signing keys not configured, generated ephemeral key:", keyring.keys[0].kid)
+	}
+	sort.SliceStable(keyring.keys, func(i, j int) bool {
+		return keyring.keys[i].activeFrom.Before(keyring.keys[j].activeFrom)
+	})
+	return keyring, nil
+}
+
+func parseSigningKey(data []byte) (signer crypto.Signer, method jwt.SigningMethod, err error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("no PEM block found")
+	}
+	// PKCS #1, SEC 1 or PKCS #8 encoded private keys
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return key, jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return key, jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return key, jwt.SigningMethodES512, nil
+		}
+		return nil, nil, fmt.Errorf("EC curve %s is not supported", key.Curve.Params().Name)
+	}
+	return nil, nil, fmt.Errorf("private key type %T is not supported", key)
+}
+
+func (keyring *signingKeyring) signingKey(now time.Time) (key *signingKey, err error) {
+	// the latest key active at now signs
+	for i := range keyring.keys {
+		if keyring.keys[i].activeFrom.After(now) {
+			break
+		}
+		key = &keyring.keys[i]
+	}
+	if key == nil {
+		return nil, errors.New("no signing key is active")
+	}
+	return key, nil
+}
+
+func (keyring *signingKeyring) publishedKeys(now time.Time) (keys []signingKey) {
+	// upcoming keys are published ahead of activation, retired ones until overlap elapses
+	for i, v := range keyring.keys {
+		if i+1 < len(keyring.keys) && !keyring.keys[i+1].activeFrom.Add(keyring.overlap).After(now) {
+			continue
+		}
+		keys = append(keys, v)
+	}
+	return keys
+}
+
+func (keyring *signingKeyring) verificationKey(kid string, now time.Time) (key *signingKey) {
+	for _, v := range keyring.publishedKeys(now) {
+		if v.kid == kid {
+			return &v
+		}
+	}
+	return nil
+}
+
+func (keyring *signingKeyring) jwks(now time.Time) (jwks JsonWebKeySet) {
+	jwks.Keys = []JsonWebKey{}
+	for _, v := range keyring.publishedKeys(now) {
+		jwk := JsonWebKey{Kid: v.kid, Use: "sig", Alg: v.method.Alg()}
+		switch publicKey := v.signer.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case *ecdsa.PublicKey:
+			// coordinates are padded to the curve size, as of RFC 7518
+			size := (publicKey.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = publicKey.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, size)))
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
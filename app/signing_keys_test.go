@@ -0,0 +1,132 @@
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	. "nrf/conf"
+	. "nrf/logs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestSigningKeys(t *testing.T) (rsaKeyFile string, ecKeyFile string) {
+	// keyring loading is logged
+	err := InitLog()
+	if err != nil {
+		t.Fatalf("Error initializing logger: %v", err)
+	}
+	dir := t.TempDir()
+	// RSA key in PKCS #1 and EC key in PKCS #8
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	ecBytes, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Error marshalling key: %v", err)
+	}
+	rsaKeyFile = filepath.Join(dir, "rsa.key")
+	ecKeyFile = filepath.Join(dir, "ec.key")
+	for file, block := range map[string]*pem.Block{
+		rsaKeyFile: {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)},
+		ecKeyFile:  {Type: "PRIVATE KEY", Bytes: ecBytes},
+	} {
+		err = os.WriteFile(file, pem.EncodeToMemory(block), 0600)
+		if err != nil {
+			t.Fatalf("Error writing key: %v", err)
+		}
+	}
+	return rsaKeyFile, ecKeyFile
+}
+
+func TestSigningKeyringRotation(t *testing.T) {
+	rsaKeyFile, ecKeyFile := writeTestSigningKeys(t)
+	now := time.Now()
+	keyring, err := loadSigningKeyring([]SigningKey{
+		{KeyId: "next", KeyFile: rsaKeyFile, ActiveFrom: now.Add(time.Hour)},
+		{KeyId: "previous", KeyFile: rsaKeyFile, ActiveFrom: now.Add(-2 * time.Hour)},
+		{KeyId: "current", KeyFile: ecKeyFile, ActiveFrom: now.Add(-30 * time.Minute)},
+	}, time.Hour)
+	assert.NoError(t, err)
+	// the latest active key signs
+	key, err := keyring.signingKey(now)
+	assert.NoError(t, err)
+	assert.Equal(t, "current", key.kid)
+	assert.Equal(t, "ES256", key.method.Alg())
+	_, err = keyring.signingKey(now.Add(-3 * time.Hour))
+	assert.Error(t, err)
+	// previous key stays published within the overlap, next key ahead of activation
+	for offset, kids := range map[time.Duration][]string{
+		0:                {"previous", "current", "next"},
+		time.Hour:        {"current", "next"},
+		2 * time.Hour:    {"next"},
+		-3 * time.Minute: {"previous", "current", "next"},
+	} {
+		var published []string
+		for _, v := range keyring.publishedKeys(now.Add(offset)) {
+			published = append(published, v.kid)
+		}
+		assert.Equal(t, kids, published, offset)
+	}
+	// tokens signed by the previous key verify until the overlap elapses
+	previous, err := keyring.signingKey(now.Add(-time.Hour))
+	assert.NoError(t, err)
+	token := jwt.NewWithClaims(previous.method, jwt.RegisteredClaims{Subject: "test"})
+	token.Header["kid"] = previous.kid
+	tokenString, err := token.SignedString(previous.signer)
+	assert.NoError(t, err)
+	_, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return keyring.verificationKey(token.Header["kid"].(string), now).signer.Public(), nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, keyring.verificationKey(previous.kid, now.Add(time.Hour)))
+	// JWKS publishes RSA and EC public keys
+	jwks := keyring.jwks(now)
+	assert.Len(t, jwks.Keys, 3)
+	for _, v := range jwks.Keys {
+		assert.Equal(t, "sig", v.Use)
+		switch v.Kty {
+		case "RSA":
+			assert.Equal(t, "RS256", v.Alg)
+			assert.Equal(t, "AQAB", v.E)
+			assert.NotEmpty(t, v.N)
+		case "EC":
+			assert.Equal(t, "ES256", v.Alg)
+			assert.Equal(t, "P-256", v.Crv)
+			assert.Len(t, v.X, 43)
+			assert.Len(t, v.Y, 43)
+		}
+	}
+}
+
+func TestLoadSigningKeyring(t *testing.T) {
+	rsaKeyFile, _ := writeTestSigningKeys(t)
+	invalidKeyFile := filepath.Join(t.TempDir(), "invalid.key")
+	_ = os.WriteFile(invalidKeyFile, []byte("invalid"), 0600)
+	// ephemeral key without configured keys
+	keyring, err := loadSigningKeyring(nil, time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, keyring.keys, 1)
+	// invalid configured keys
+	for _, v := range [][]SigningKey{
+		{{KeyFile: rsaKeyFile}},
+		{{KeyId: "a", KeyFile: rsaKeyFile}, {KeyId: "a", KeyFile: rsaKeyFile}},
+		{{KeyId: "a", KeyFile: filepath.Join(t.TempDir(), "missing.key")}},
+		{{KeyId: "a", KeyFile: invalidKeyFile}},
+	} {
+		_, err = loadSigningKeyring(v, time.Hour)
+		assert.Error(t, err, v)
+	}
+}
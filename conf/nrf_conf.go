@@ -4,15 +4,27 @@ import (
 	"gopkg.in/yaml.v3"
 	"io"
 	"os"
+	"time"
 )
 
 type NRFConf struct {
-	SBIIPAddr              string         `json:"sbiIPAddr" yaml:"sbiIPAddr"`
-	SBIPort                int            `json:"sbiPort" yaml:"sbiPort"`
-	SBITLSSettings         SBITLSSettings `json:"sbiTLSSettings" yaml:"sbiTLSSettings"`
-	AcceptNFHeartBeatTimer bool           `json:"acceptNFHeartBeatTimer" yaml:"acceptNFHeartBeatTimer"`
-	DefaultHeartBeatTimer  int            `json:"defaultHeartBeatTimer" yaml:"defaultHeartBeatTimer"`
-	AllowedSharedData      bool           `json:"allowedSharedData" yaml:"allowedSharedData"`
+	NRFInstanceId             string               `json:"nrfInstanceId" yaml:"nrfInstanceId"`
+	SBIIPAddr                 string               `json:"sbiIPAddr" yaml:"sbiIPAddr"`
+	SBIPort                   int                  `json:"sbiPort" yaml:"sbiPort"`
+	SBITLSSettings            SBITLSSettings       `json:"sbiTLSSettings" yaml:"sbiTLSSettings"`
+	AcceptNFHeartBeatTimer    bool                 `json:"acceptNFHeartBeatTimer" yaml:"acceptNFHeartBeatTimer"`
+	DefaultHeartBeatTimer     int                  `json:"defaultHeartBeatTimer" yaml:"defaultHeartBeatTimer"`
+	HeartBeatGracePeriod      int                  `json:"heartBeatGracePeriod" yaml:"heartBeatGracePeriod"`
+	HeartBeatDeregisterPeriod int                  `json:"heartBeatDeregisterPeriod" yaml:"heartBeatDeregisterPeriod"`
+	AllowedSharedData         bool                 `json:"allowedSharedData" yaml:"allowedSharedData"`
+	SubscriptionValidityTime  int                  `json:"subscriptionValidityTime" yaml:"subscriptionValidityTime"`
+	DiscoveryValidityPeriod   int                  `json:"discoveryValidityPeriod" yaml:"discoveryValidityPeriod"`
+	MaxStoredSearches         int                  `json:"maxStoredSearches" yaml:"maxStoredSearches"`
+	MaxStoredSearchSize       int                  `json:"maxStoredSearchSize" yaml:"maxStoredSearchSize"`
+	CanaryReleaseShare        int                  `json:"canaryReleaseShare" yaml:"canaryReleaseShare"`
+	AccessTokenValidityTime   int                  `json:"accessTokenValidityTime" yaml:"accessTokenValidityTime"`
+	OAuth2Settings            OAuth2Settings       `json:"oauth2Settings" yaml:"oauth2Settings"`
+	NotificationSettings      NotificationSettings `json:"notificationSettings" yaml:"notificationSettings"`
 }
 
 type SBITLSSettings struct {
@@ -23,6 +35,28 @@ type SBITLSSettings struct {
 	CAFile     string `json:"caFile" yaml:"caFile"`
 }
 
+type OAuth2Settings struct {
+	AuthorizationRequired   bool         `json:"authorizationRequired" yaml:"authorizationRequired"`
+	AuthorizationExemptions []string     `json:"authorizationExemptions" yaml:"authorizationExemptions"`
+	ClientAssertionRequired bool         `json:"clientAssertionRequired" yaml:"clientAssertionRequired"`
+	SigningKeys             []SigningKey `json:"signingKeys" yaml:"signingKeys"`
+}
+
+type SigningKey struct {
+	KeyId      string    `json:"kid" yaml:"kid"`
+	KeyFile    string    `json:"keyFile" yaml:"keyFile"`
+	ActiveFrom time.Time `json:"activeFrom" yaml:"activeFrom"`
+}
+
+type NotificationSettings struct {
+	Workers        int `json:"workers" yaml:"workers"`
+	QueueSize      int `json:"queueSize" yaml:"queueSize"`
+	MaxRetries     int `json:"maxRetries" yaml:"maxRetries"`
+	RetryInterval  int `json:"retryInterval" yaml:"retryInterval"`
+	Timeout        int `json:"timeout" yaml:"timeout"`
+	DeadLetterSize int `json:"deadLetterSize" yaml:"deadLetterSize"`
+}
+
 func MarshalTo(file string, t interface{}) (err error) {
 	return marshalTo(file, t)
 }
@@ -1,17 +1,241 @@
 package data
 
+import "time"
+
 type NFProfile struct {
-	NFInstanceId   string      `json:"nfInstanceId" yaml:"nfInstanceId" binding:"required"`
-	NFType         string      `json:"nfType" yaml:"nfType" binding:"required"`
-	NFStatus       string      `json:"nfStatus" yaml:"nfStatus" binding:"required"`
-	NFInstanceName string      `json:"nfInstanceName" yaml:"nfInstanceName" binding:"omitempty"`
-	HeartBeatTimer int         `json:"heartBeatTimer" yaml:"heartBeatTimer" binding:"omitempty"`
-	NFServices     []NFService `json:"nfServices" yaml:"nfServices" binding:"omitempty"`
+	NFInstanceId         string               `json:"nfInstanceId" yaml:"nfInstanceId" binding:"required"`
+	NFType               string               `json:"nfType" yaml:"nfType" binding:"required"`
+	NFStatus             string               `json:"nfStatus" yaml:"nfStatus" binding:"required"`
+	NFInstanceName       string               `json:"nfInstanceName" yaml:"nfInstanceName" binding:"omitempty"`
+	HeartBeatTimer       int                  `json:"heartBeatTimer" yaml:"heartBeatTimer" binding:"omitempty"`
+	PlmnList             []PlmnId             `json:"plmnList,omitempty" yaml:"plmnList,omitempty" binding:"omitempty,dive"`
+	SNssais              []Snssai             `json:"sNssais,omitempty" yaml:"sNssais,omitempty" binding:"omitempty,dive"`
+	PerPlmnSnssaiList    []PlmnSnssai         `json:"perPlmnSnssaiList,omitempty" yaml:"perPlmnSnssaiList,omitempty" binding:"omitempty,dive"`
+	NsiList              []string             `json:"nsiList,omitempty" yaml:"nsiList,omitempty" binding:"omitempty,dive,required"`
+	Fqdn                 string               `json:"fqdn,omitempty" yaml:"fqdn,omitempty" binding:"omitempty,fqdn"`
+	Ipv4Addresses        []string             `json:"ipv4Addresses,omitempty" yaml:"ipv4Addresses,omitempty" binding:"omitempty,dive,ipv4"`
+	Ipv6Addresses        []string             `json:"ipv6Addresses,omitempty" yaml:"ipv6Addresses,omitempty" binding:"omitempty,dive,ipv6"`
+	AllowedPlmns         []PlmnId             `json:"allowedPlmns,omitempty" yaml:"allowedPlmns,omitempty" binding:"omitempty,dive"`
+	AllowedNfTypes       []string             `json:"allowedNfTypes,omitempty" yaml:"allowedNfTypes,omitempty" binding:"omitempty"`
+	AllowedNssais        []Snssai             `json:"allowedNssais,omitempty" yaml:"allowedNssais,omitempty" binding:"omitempty,dive"`
+	AllowedNfDomains     []string             `json:"allowedNfDomains,omitempty" yaml:"allowedNfDomains,omitempty" binding:"omitempty"`
+	Priority             int                  `json:"priority,omitempty" yaml:"priority,omitempty" binding:"omitempty,min=0,max=65535"`
+	Capacity             int                  `json:"capacity,omitempty" yaml:"capacity,omitempty" binding:"omitempty,min=0,max=65535"`
+	Load                 int                  `json:"load,omitempty" yaml:"load,omitempty" binding:"omitempty,min=0,max=100"`
+	Locality             string               `json:"locality,omitempty" yaml:"locality,omitempty" binding:"omitempty"`
+	RecoveryTime         *time.Time           `json:"recoveryTime,omitempty" yaml:"recoveryTime,omitempty" binding:"omitempty"`
+	NFServicePersistence bool                 `json:"nfServicePersistence,omitempty" yaml:"nfServicePersistence,omitempty" binding:"omitempty"`
+	NrfSupportedFeatures string               `json:"nrfSupportedFeatures,omitempty" yaml:"nrfSupportedFeatures,omitempty" binding:"omitempty"`
+	NfSetIdList          []string             `json:"nfSetIdList,omitempty" yaml:"nfSetIdList,omitempty" binding:"omitempty"`
+	AmfInfo              *AmfInfo             `json:"amfInfo,omitempty" yaml:"amfInfo,omitempty" binding:"omitempty"`
+	AmfInfoList          map[string]AmfInfo   `json:"amfInfoList,omitempty" yaml:"amfInfoList,omitempty" binding:"omitempty,dive"`
+	SmfInfo              *SmfInfo             `json:"smfInfo,omitempty" yaml:"smfInfo,omitempty" binding:"omitempty"`
+	SmfInfoList          map[string]SmfInfo   `json:"smfInfoList,omitempty" yaml:"smfInfoList,omitempty" binding:"omitempty,dive"`
+	UdmInfo              *UdmInfo             `json:"udmInfo,omitempty" yaml:"udmInfo,omitempty" binding:"omitempty"`
+	UdmInfoList          map[string]UdmInfo   `json:"udmInfoList,omitempty" yaml:"udmInfoList,omitempty" binding:"omitempty,dive"`
+	AusfInfo             *AusfInfo            `json:"ausfInfo,omitempty" yaml:"ausfInfo,omitempty" binding:"omitempty"`
+	AusfInfoList         map[string]AusfInfo  `json:"ausfInfoList,omitempty" yaml:"ausfInfoList,omitempty" binding:"omitempty,dive"`
+	UpfInfo              *UpfInfo             `json:"upfInfo,omitempty" yaml:"upfInfo,omitempty" binding:"omitempty"`
+	UpfInfoList          map[string]UpfInfo   `json:"upfInfoList,omitempty" yaml:"upfInfoList,omitempty" binding:"omitempty,dive"`
+	PcfInfo              *PcfInfo             `json:"pcfInfo,omitempty" yaml:"pcfInfo,omitempty" binding:"omitempty"`
+	PcfInfoList          map[string]PcfInfo   `json:"pcfInfoList,omitempty" yaml:"pcfInfoList,omitempty" binding:"omitempty,dive"`
+	BsfInfo              *BsfInfo             `json:"bsfInfo,omitempty" yaml:"bsfInfo,omitempty" binding:"omitempty"`
+	BsfInfoList          map[string]BsfInfo   `json:"bsfInfoList,omitempty" yaml:"bsfInfoList,omitempty" binding:"omitempty,dive"`
+	ChfInfo              *ChfInfo             `json:"chfInfo,omitempty" yaml:"chfInfo,omitempty" binding:"omitempty"`
+	ChfInfoList          map[string]ChfInfo   `json:"chfInfoList,omitempty" yaml:"chfInfoList,omitempty" binding:"omitempty,dive"`
+	NwdafInfo            *NwdafInfo           `json:"nwdafInfo,omitempty" yaml:"nwdafInfo,omitempty" binding:"omitempty"`
+	NwdafInfoList        map[string]NwdafInfo `json:"nwdafInfoList,omitempty" yaml:"nwdafInfoList,omitempty" binding:"omitempty,dive"`
+	NFServices           []NFService          `json:"nfServices" yaml:"nfServices" binding:"omitempty,dive"`
 }
 
 type NFService struct {
-	ServiceInstanceId string `json:"serviceInstanceId" yaml:"serviceInstanceId" binding:"required"`
-	SupportedFeatures string `json:"supportedFeatures" yaml:"supportedFeatures" binding:"omitempty"`
+	ServiceInstanceId  string             `json:"serviceInstanceId" yaml:"serviceInstanceId" binding:"required"`
+	ServiceName        string             `json:"serviceName" yaml:"serviceName" binding:"omitempty"`
+	Versions           []NFServiceVersion `json:"versions" yaml:"versions" binding:"omitempty,dive"`
+	Scheme             string             `json:"scheme" yaml:"scheme" binding:"omitempty,oneof=http https"`
+	NFServiceStatus    string             `json:"nfServiceStatus,omitempty" yaml:"nfServiceStatus,omitempty" binding:"omitempty,oneof=REGISTERED SUSPENDED UNDISCOVERABLE CANARY_RELEASE"`
+	Fqdn               string             `json:"fqdn,omitempty" yaml:"fqdn,omitempty" binding:"omitempty,fqdn"`
+	IpEndPoints        []IpEndPoint       `json:"ipEndPoints,omitempty" yaml:"ipEndPoints,omitempty" binding:"omitempty,dive"`
+	ApiPrefix          string             `json:"apiPrefix,omitempty" yaml:"apiPrefix,omitempty" binding:"omitempty,url"`
+	AllowedPlmns       []PlmnId           `json:"allowedPlmns,omitempty" yaml:"allowedPlmns,omitempty" binding:"omitempty,dive"`
+	AllowedNfTypes     []string           `json:"allowedNfTypes,omitempty" yaml:"allowedNfTypes,omitempty" binding:"omitempty"`
+	AllowedNssais      []Snssai           `json:"allowedNssais,omitempty" yaml:"allowedNssais,omitempty" binding:"omitempty,dive"`
+	AllowedNfDomains   []string           `json:"allowedNfDomains,omitempty" yaml:"allowedNfDomains,omitempty" binding:"omitempty"`
+	SNssais            []Snssai           `json:"sNssais,omitempty" yaml:"sNssais,omitempty" binding:"omitempty,dive"`
+	Priority           int                `json:"priority,omitempty" yaml:"priority,omitempty" binding:"omitempty,min=0,max=65535"`
+	Capacity           int                `json:"capacity,omitempty" yaml:"capacity,omitempty" binding:"omitempty,min=0,max=65535"`
+	Load               int                `json:"load,omitempty" yaml:"load,omitempty" binding:"omitempty,min=0,max=100"`
+	Oauth2Required     bool               `json:"oauth2Required,omitempty" yaml:"oauth2Required,omitempty" binding:"omitempty"`
+	NfServiceSetIdList []string           `json:"nfServiceSetIdList,omitempty" yaml:"nfServiceSetIdList,omitempty" binding:"omitempty"`
+	SupportedFeatures  string             `json:"supportedFeatures" yaml:"supportedFeatures" binding:"omitempty"`
+}
+
+type NFServiceVersion struct {
+	ApiVersionInUri string     `json:"apiVersionInUri" yaml:"apiVersionInUri" binding:"required"`
+	ApiFullVersion  string     `json:"apiFullVersion" yaml:"apiFullVersion" binding:"required"`
+	Expiry          *time.Time `json:"expiry,omitempty" yaml:"expiry,omitempty" binding:"omitempty"`
+}
+
+type IpEndPoint struct {
+	Ipv4Address string `json:"ipv4Address,omitempty" yaml:"ipv4Address,omitempty" binding:"omitempty,ipv4"`
+	Ipv6Address string `json:"ipv6Address,omitempty" yaml:"ipv6Address,omitempty" binding:"omitempty,ipv6"`
+	Transport   string `json:"transport,omitempty" yaml:"transport,omitempty" binding:"omitempty,oneof=TCP"`
+	Port        int    `json:"port,omitempty" yaml:"port,omitempty" binding:"omitempty,min=0,max=65535"`
+}
+
+type PlmnId struct {
+	Mcc string `json:"mcc" yaml:"mcc" binding:"required,len=3,numeric"`
+	Mnc string `json:"mnc" yaml:"mnc" binding:"required,min=2,max=3,numeric"`
+}
+
+type Snssai struct {
+	Sst int    `json:"sst" yaml:"sst" binding:"min=0,max=255"`
+	Sd  string `json:"sd,omitempty" yaml:"sd,omitempty" binding:"omitempty,len=6,hexadecimal"`
+}
+
+type PlmnSnssai struct {
+	PlmnId     PlmnId   `json:"plmnId" yaml:"plmnId" binding:"required"`
+	SNssaiList []Snssai `json:"sNssaiList" yaml:"sNssaiList" binding:"required,min=1,dive"`
+	Nid        string   `json:"nid,omitempty" yaml:"nid,omitempty" binding:"omitempty,len=11,hexadecimal"`
+}
+
+type Guami struct {
+	PlmnId PlmnId `json:"plmnId" yaml:"plmnId" binding:"required"`
+	AmfId  string `json:"amfId" yaml:"amfId" binding:"required,len=6,hexadecimal"`
+}
+
+type Tai struct {
+	PlmnId PlmnId `json:"plmnId" yaml:"plmnId" binding:"required"`
+	Tac    string `json:"tac" yaml:"tac" binding:"required,hexadecimal"`
+}
+
+type TaiRange struct {
+	PlmnId       PlmnId     `json:"plmnId" yaml:"plmnId" binding:"required"`
+	TacRangeList []TacRange `json:"tacRangeList" yaml:"tacRangeList" binding:"required,min=1,dive"`
+}
+
+type TacRange struct {
+	Start   string `json:"start,omitempty" yaml:"start,omitempty" binding:"omitempty"`
+	End     string `json:"end,omitempty" yaml:"end,omitempty" binding:"omitempty"`
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" binding:"omitempty"`
+}
+
+type AmfInfo struct {
+	AmfSetId     string     `json:"amfSetId" yaml:"amfSetId" binding:"required,len=3,hexadecimal"`
+	AmfRegionId  string     `json:"amfRegionId" yaml:"amfRegionId" binding:"required,len=2,hexadecimal"`
+	GuamiList    []Guami    `json:"guamiList" yaml:"guamiList" binding:"required,min=1,dive"`
+	TaiList      []Tai      `json:"taiList,omitempty" yaml:"taiList,omitempty" binding:"omitempty,dive"`
+	TaiRangeList []TaiRange `json:"taiRangeList,omitempty" yaml:"taiRangeList,omitempty" binding:"omitempty,dive"`
+	// GUAMIs this AMF backs up on failure or planned removal of their serving AMF
+	BackupInfoAmfFailure []Guami `json:"backupInfoAmfFailure,omitempty" yaml:"backupInfoAmfFailure,omitempty" binding:"omitempty,dive"`
+	BackupInfoAmfRemoval []Guami `json:"backupInfoAmfRemoval,omitempty" yaml:"backupInfoAmfRemoval,omitempty" binding:"omitempty,dive"`
+}
+
+type SmfInfo struct {
+	SNssaiSmfInfoList []SnssaiSmfInfoItem `json:"sNssaiSmfInfoList" yaml:"sNssaiSmfInfoList" binding:"required,min=1,dive"`
+	TaiList           []Tai               `json:"taiList,omitempty" yaml:"taiList,omitempty" binding:"omitempty,dive"`
+	TaiRangeList      []TaiRange          `json:"taiRangeList,omitempty" yaml:"taiRangeList,omitempty" binding:"omitempty,dive"`
+	PgwFqdn           string              `json:"pgwFqdn,omitempty" yaml:"pgwFqdn,omitempty" binding:"omitempty,fqdn"`
+	AccessType        []string            `json:"accessType,omitempty" yaml:"accessType,omitempty" binding:"omitempty,dive,oneof=3GPP_ACCESS NON_3GPP_ACCESS"`
+	Priority          int                 `json:"priority,omitempty" yaml:"priority,omitempty" binding:"omitempty,min=0,max=65535"`
+	VsmfSupportInd    bool                `json:"vsmfSupportInd,omitempty" yaml:"vsmfSupportInd,omitempty" binding:"omitempty"`
+}
+
+type SnssaiSmfInfoItem struct {
+	SNssai         Snssai           `json:"sNssai" yaml:"sNssai" binding:"required"`
+	DnnSmfInfoList []DnnSmfInfoItem `json:"dnnSmfInfoList" yaml:"dnnSmfInfoList" binding:"required,min=1,dive"`
+}
+
+type DnnSmfInfoItem struct {
+	Dnn string `json:"dnn" yaml:"dnn" binding:"required"`
+}
+
+type UdmInfo struct {
+	GroupId                        string          `json:"groupId,omitempty" yaml:"groupId,omitempty" binding:"omitempty"`
+	SupiRanges                     []SupiRange     `json:"supiRanges,omitempty" yaml:"supiRanges,omitempty" binding:"omitempty,dive"`
+	GpsiRanges                     []IdentityRange `json:"gpsiRanges,omitempty" yaml:"gpsiRanges,omitempty" binding:"omitempty,dive"`
+	ExternalGroupIdentifiersRanges []IdentityRange `json:"externalGroupIdentifiersRanges,omitempty" yaml:"externalGroupIdentifiersRanges,omitempty" binding:"omitempty,dive"`
+	RoutingIndicators              []string        `json:"routingIndicators,omitempty" yaml:"routingIndicators,omitempty" binding:"omitempty"`
+}
+
+type AusfInfo struct {
+	GroupId           string      `json:"groupId,omitempty" yaml:"groupId,omitempty" binding:"omitempty"`
+	SupiRanges        []SupiRange `json:"supiRanges,omitempty" yaml:"supiRanges,omitempty" binding:"omitempty,dive"`
+	RoutingIndicators []string    `json:"routingIndicators,omitempty" yaml:"routingIndicators,omitempty" binding:"omitempty"`
+}
+
+type SupiRange struct {
+	Start   string `json:"start,omitempty" yaml:"start,omitempty" binding:"omitempty"`
+	End     string `json:"end,omitempty" yaml:"end,omitempty" binding:"omitempty"`
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" binding:"omitempty"`
+}
+
+type IdentityRange struct {
+	Start   string `json:"start,omitempty" yaml:"start,omitempty" binding:"omitempty"`
+	End     string `json:"end,omitempty" yaml:"end,omitempty" binding:"omitempty"`
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" binding:"omitempty"`
+}
+
+type UpfInfo struct {
+	SNssaiUpfInfoList    []SnssaiUpfInfoItem    `json:"sNssaiUpfInfoList" yaml:"sNssaiUpfInfoList" binding:"required,min=1,dive"`
+	SmfServingArea       []string               `json:"smfServingArea,omitempty" yaml:"smfServingArea,omitempty" binding:"omitempty"`
+	InterfaceUpfInfoList []InterfaceUpfInfoItem `json:"interfaceUpfInfoList,omitempty" yaml:"interfaceUpfInfoList,omitempty" binding:"omitempty,dive"`
+	IwkEpsInd            bool                   `json:"iwkEpsInd,omitempty" yaml:"iwkEpsInd,omitempty" binding:"omitempty"`
+	PduSessionTypes      []string               `json:"pduSessionTypes,omitempty" yaml:"pduSessionTypes,omitempty" binding:"omitempty,dive,oneof=IPV4 IPV6 IPV4V6 UNSTRUCTURED ETHERNET"`
+	TaiList              []Tai                  `json:"taiList,omitempty" yaml:"taiList,omitempty" binding:"omitempty,dive"`
+	TaiRangeList         []TaiRange             `json:"taiRangeList,omitempty" yaml:"taiRangeList,omitempty" binding:"omitempty,dive"`
+}
+
+type SnssaiUpfInfoItem struct {
+	SNssai         Snssai           `json:"sNssai" yaml:"sNssai" binding:"required"`
+	DnnUpfInfoList []DnnUpfInfoItem `json:"dnnUpfInfoList" yaml:"dnnUpfInfoList" binding:"required,min=1,dive"`
+}
+
+type DnnUpfInfoItem struct {
+	Dnn string `json:"dnn" yaml:"dnn" binding:"required"`
+}
+
+type InterfaceUpfInfoItem struct {
+	InterfaceType         string   `json:"interfaceType" yaml:"interfaceType" binding:"required,oneof=N3 N6 N9 DATA_FORWARDING"`
+	Ipv4EndpointAddresses []string `json:"ipv4EndpointAddresses,omitempty" yaml:"ipv4EndpointAddresses,omitempty" binding:"omitempty,dive,ipv4"`
+	Ipv6EndpointAddresses []string `json:"ipv6EndpointAddresses,omitempty" yaml:"ipv6EndpointAddresses,omitempty" binding:"omitempty,dive,ipv6"`
+	EndpointFqdn          string   `json:"endpointFqdn,omitempty" yaml:"endpointFqdn,omitempty" binding:"omitempty,fqdn"`
+	NetworkInstance       string   `json:"networkInstance,omitempty" yaml:"networkInstance,omitempty" binding:"omitempty"`
+}
+
+type PcfInfo struct {
+	GroupId       string          `json:"groupId,omitempty" yaml:"groupId,omitempty" binding:"omitempty"`
+	DnnList       []string        `json:"dnnList,omitempty" yaml:"dnnList,omitempty" binding:"omitempty"`
+	SupiRanges    []SupiRange     `json:"supiRanges,omitempty" yaml:"supiRanges,omitempty" binding:"omitempty,dive"`
+	GpsiRanges    []IdentityRange `json:"gpsiRanges,omitempty" yaml:"gpsiRanges,omitempty" binding:"omitempty,dive"`
+	RxDiamHost    string          `json:"rxDiamHost,omitempty" yaml:"rxDiamHost,omitempty" binding:"omitempty"`
+	RxDiamRealm   string          `json:"rxDiamRealm,omitempty" yaml:"rxDiamRealm,omitempty" binding:"omitempty"`
+	V2xSupportInd bool            `json:"v2xSupportInd,omitempty" yaml:"v2xSupportInd,omitempty" binding:"omitempty"`
+}
+
+type BsfInfo struct {
+	DnnList           []string           `json:"dnnList,omitempty" yaml:"dnnList,omitempty" binding:"omitempty"`
+	IpDomainList      []string           `json:"ipDomainList,omitempty" yaml:"ipDomainList,omitempty" binding:"omitempty"`
+	Ipv4AddressRanges []Ipv4AddressRange `json:"ipv4AddressRanges,omitempty" yaml:"ipv4AddressRanges,omitempty" binding:"omitempty,dive"`
+}
+
+type Ipv4AddressRange struct {
+	Start string `json:"start" yaml:"start" binding:"required,ipv4"`
+	End   string `json:"end" yaml:"end" binding:"required,ipv4"`
+}
+
+type ChfInfo struct {
+	GroupId              string          `json:"groupId,omitempty" yaml:"groupId,omitempty" binding:"omitempty"`
+	SupiRangeList        []SupiRange     `json:"supiRangeList,omitempty" yaml:"supiRangeList,omitempty" binding:"omitempty,dive"`
+	GpsiRangeList        []IdentityRange `json:"gpsiRangeList,omitempty" yaml:"gpsiRangeList,omitempty" binding:"omitempty,dive"`
+	PrimaryChfInstance   string          `json:"primaryChfInstance,omitempty" yaml:"primaryChfInstance,omitempty" binding:"omitempty,uuid"`
+	SecondaryChfInstance string          `json:"secondaryChfInstance,omitempty" yaml:"secondaryChfInstance,omitempty" binding:"omitempty,uuid"`
+}
+
+type NwdafInfo struct {
+	EventIds    []string `json:"eventIds,omitempty" yaml:"eventIds,omitempty" binding:"omitempty"`
+	NwdafEvents []string `json:"nwdafEvents,omitempty" yaml:"nwdafEvents,omitempty" binding:"omitempty"`
+	TaiList     []Tai    `json:"taiList,omitempty" yaml:"taiList,omitempty" binding:"omitempty,dive"`
 }
 
 type NFProfileRegistrationError struct {
@@ -39,6 +263,87 @@ type SharedData struct {
 }
 
 type UriList struct {
-	Links          []string `json:"_links" yaml:"_links" binding:"omitempty"`
-	TotalItemCount int      `json:"totalItemCount" yaml:"totalItemCount" binding:"omitempty"`
+	Links          UriListLinks `json:"_links" yaml:"_links" binding:"omitempty"`
+	TotalItemCount int          `json:"totalItemCount" yaml:"totalItemCount" binding:"omitempty"`
+}
+
+type UriListLinks struct {
+	Item []Link `json:"item" yaml:"item" binding:"omitempty"`
+	Self *Link  `json:"self,omitempty" yaml:"self,omitempty" binding:"omitempty"`
+	Next *Link  `json:"next,omitempty" yaml:"next,omitempty" binding:"omitempty"`
+	Prev *Link  `json:"prev,omitempty" yaml:"prev,omitempty" binding:"omitempty"`
+}
+
+type Link struct {
+	Href string `json:"href" yaml:"href" binding:"omitempty"`
+}
+
+type SearchResult struct {
+	ValidityPeriod       int         `json:"validityPeriod" yaml:"validityPeriod" binding:"omitempty"`
+	NFInstances          []NFProfile `json:"nfInstances" yaml:"nfInstances" binding:"omitempty"`
+	NumNfInstComplete    int         `json:"numNfInstComplete" yaml:"numNfInstComplete" binding:"omitempty"`
+	NrfSupportedFeatures string      `json:"nrfSupportedFeatures,omitempty" yaml:"nrfSupportedFeatures,omitempty" binding:"omitempty"`
+	SearchId             string      `json:"searchId,omitempty" yaml:"searchId,omitempty" binding:"omitempty"`
+}
+
+type StoredSearchResult struct {
+	NFInstances []NFProfile `json:"nfInstances" yaml:"nfInstances" binding:"omitempty"`
+}
+
+type AccessTokenRsp struct {
+	AccessToken string `json:"access_token" yaml:"access_token" binding:"required"`
+	TokenType   string `json:"token_type" yaml:"token_type" binding:"required"`
+	ExpiresIn   int    `json:"expires_in,omitempty" yaml:"expires_in,omitempty" binding:"omitempty"`
+	Scope       string `json:"scope,omitempty" yaml:"scope,omitempty" binding:"omitempty"`
+}
+
+type AccessTokenErr struct {
+	Error            string `json:"error" yaml:"error" binding:"required,oneof=invalid_request invalid_client invalid_grant unauthorized_client unsupported_grant_type invalid_scope"`
+	ErrorDescription string `json:"error_description,omitempty" yaml:"error_description,omitempty" binding:"omitempty"`
+	ErrorUri         string `json:"error_uri,omitempty" yaml:"error_uri,omitempty" binding:"omitempty"`
+}
+
+type JsonWebKeySet struct {
+	Keys []JsonWebKey `json:"keys" yaml:"keys" binding:"omitempty"`
+}
+
+type JsonWebKey struct {
+	Kty string `json:"kty" yaml:"kty" binding:"required,oneof=RSA EC"`
+	Kid string `json:"kid" yaml:"kid" binding:"required"`
+	Use string `json:"use,omitempty" yaml:"use,omitempty" binding:"omitempty"`
+	Alg string `json:"alg,omitempty" yaml:"alg,omitempty" binding:"omitempty"`
+	N   string `json:"n,omitempty" yaml:"n,omitempty" binding:"omitempty"`
+	E   string `json:"e,omitempty" yaml:"e,omitempty" binding:"omitempty"`
+	Crv string `json:"crv,omitempty" yaml:"crv,omitempty" binding:"omitempty"`
+	X   string `json:"x,omitempty" yaml:"x,omitempty" binding:"omitempty"`
+	Y   string `json:"y,omitempty" yaml:"y,omitempty" binding:"omitempty"`
+}
+
+type SubscriptionData struct {
+	NFStatusNotificationUri string      `json:"nfStatusNotificationUri" yaml:"nfStatusNotificationUri" binding:"required,url"`
+	ReqNFInstanceId         string      `json:"reqNfInstanceId,omitempty" yaml:"reqNfInstanceId,omitempty" binding:"omitempty,uuid"`
+	SubscrCond              *SubscrCond `json:"subscrCond,omitempty" yaml:"subscrCond,omitempty" binding:"omitempty"`
+	SubscriptionId          string      `json:"subscriptionId" yaml:"subscriptionId" binding:"omitempty"`
+	ValidityTime            *time.Time  `json:"validityTime,omitempty" yaml:"validityTime,omitempty" binding:"omitempty"`
+	ReqNotifEvents          []string    `json:"reqNotifEvents,omitempty" yaml:"reqNotifEvents,omitempty" binding:"omitempty,dive,oneof=NF_REGISTERED NF_DEREGISTERED NF_PROFILE_CHANGED"`
+	ReqNFType               string      `json:"reqNfType,omitempty" yaml:"reqNfType,omitempty" binding:"omitempty"`
+}
+
+type SubscrCond struct {
+	NFInstanceId string `json:"nfInstanceId,omitempty" yaml:"nfInstanceId,omitempty" binding:"omitempty,uuid"`
+	NFType       string `json:"nfType,omitempty" yaml:"nfType,omitempty" binding:"omitempty"`
+	ServiceName  string `json:"serviceName,omitempty" yaml:"serviceName,omitempty" binding:"omitempty"`
+}
+
+type NotificationData struct {
+	Event         string     `json:"event" yaml:"event" binding:"required,oneof=NF_REGISTERED NF_DEREGISTERED NF_PROFILE_CHANGED"`
+	NFInstanceUri string     `json:"nfInstanceUri" yaml:"nfInstanceUri" binding:"required"`
+	NFProfile     *NFProfile `json:"nfProfile,omitempty" yaml:"nfProfile,omitempty" binding:"omitempty"`
+}
+
+type PatchItem struct {
+	Op    string      `json:"op" yaml:"op" binding:"required,oneof=add remove replace move copy test"`
+	Path  string      `json:"path" yaml:"path" binding:"required"`
+	From  string      `json:"from,omitempty" yaml:"from,omitempty" binding:"omitempty"`
+	Value interface{} `json:"value,omitempty" yaml:"value,omitempty" binding:"omitempty"`
 }
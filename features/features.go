@@ -0,0 +1,110 @@
+package features
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var supportedFeaturesPattern = regexp.MustCompile(`^[A-Fa-f0-9]*$`)
+
+type Feature struct {
+	Number int
+	Name   string
+}
+
+type API struct {
+	Name     string
+	Features []Feature
+}
+
+// NFManagement lists the features of the nnrf-nfm API supported by NRF
+var NFManagement = API{
+	Name: "nnrf-nfm",
+	Features: []Feature{
+		{Number: 2, Name: "Empty-Objects-NRF-Info"},
+		{Number: 3, Name: "Shared-Data"},
+	},
+}
+
+// NFDiscovery lists the features of the nnrf-disc API supported by NRF
+var NFDiscovery = API{
+	Name: "nnrf-disc",
+	Features: []Feature{
+		{Number: 2, Name: "Query-Params-Ext1"},
+		{Number: 5, Name: "Query-Params-Ext2"},
+		{Number: 7, Name: "Query-Params-Ext3"},
+		{Number: 8, Name: "Query-Params-Ext4"},
+	},
+}
+
+func Parse(supportedFeatures string) (bits *big.Int, err error) {
+	bits = new(big.Int)
+	// check hex encoded bitmask
+	if !supportedFeaturesPattern.MatchString(supportedFeatures) {
+		return nil, fmt.Errorf("supported features %q is not a hexadecimal string", supportedFeatures)
+	}
+	if supportedFeatures == "" {
+		return bits, nil
+	}
+	// feature number n is bit n-1 counted from the last hex digit
+	bits.SetString(supportedFeatures, 16)
+	return bits, nil
+}
+
+func Format(bits *big.Int) (supportedFeatures string) {
+	if bits == nil {
+		return "0"
+	}
+	return strings.ToUpper(bits.Text(16))
+}
+
+func And(a string, b string) (supportedFeatures string, err error) {
+	x, err := Parse(a)
+	if err != nil {
+		return "", err
+	}
+	y, err := Parse(b)
+	if err != nil {
+		return "", err
+	}
+	return Format(new(big.Int).And(x, y)), nil
+}
+
+func Has(supportedFeatures string, name string, api API) (b bool) {
+	bits, err := Parse(supportedFeatures)
+	if err != nil {
+		return false
+	}
+	for _, v := range api.Features {
+		if v.Name == name {
+			return bits.Bit(v.Number-1) == 1
+		}
+	}
+	return false
+}
+
+func (api API) Supported() (supportedFeatures string) {
+	bits := new(big.Int)
+	for _, v := range api.Features {
+		bits.SetBit(bits, v.Number-1, 1)
+	}
+	return Format(bits)
+}
+
+func (api API) Without(name string) API {
+	// copy of api without the named feature, for features disabled by configuration
+	without := API{Name: api.Name}
+	for _, v := range api.Features {
+		if v.Name != name {
+			without.Features = append(without.Features, v)
+		}
+	}
+	return without
+}
+
+func (api API) Negotiate(requesterFeatures string) (supportedFeatures string, err error) {
+	// unknown requester bits are dropped by the AND with the API supported features
+	return And(requesterFeatures, api.Supported())
+}
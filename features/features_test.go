@@ -0,0 +1,68 @@
+package features
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	bits, err := Parse("1A")
+	if err != nil {
+		t.Fatal("Error Parse supported features:", err)
+	}
+	assert.Equal(t, int64(0x1a), bits.Int64())
+	bits, err = Parse("")
+	if err != nil {
+		t.Fatal("Error Parse supported features:", err)
+	}
+	assert.Equal(t, int64(0), bits.Int64())
+	_, err = Parse("0x1A")
+	assert.Error(t, err)
+}
+
+func TestAnd(t *testing.T) {
+	supportedFeatures, err := And("0000000000000000000000000000000F", "6")
+	if err != nil {
+		t.Fatal("Error And supported features:", err)
+	}
+	assert.Equal(t, "6", supportedFeatures)
+	supportedFeatures, err = And("F0", "0F")
+	if err != nil {
+		t.Fatal("Error And supported features:", err)
+	}
+	assert.Equal(t, "0", supportedFeatures)
+	_, err = And("G", "1")
+	assert.Error(t, err)
+}
+
+func TestHas(t *testing.T) {
+	assert.False(t, Has("1", "Service-Map", NFManagement))
+	assert.False(t, Has("2", "Shared-Data", NFManagement))
+	assert.True(t, Has("4", "Shared-Data", NFManagement))
+	assert.True(t, Has("80", "Query-Params-Ext4", NFDiscovery))
+	assert.False(t, Has("FF", "Unknown", NFDiscovery))
+}
+
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, "6", NFManagement.Supported())
+	assert.Equal(t, "D2", NFDiscovery.Supported())
+	// unknown bits are ignored
+	supportedFeatures, err := NFManagement.Negotiate("FFF5")
+	if err != nil {
+		t.Fatal("Error Negotiate supported features:", err)
+	}
+	assert.Equal(t, "4", supportedFeatures)
+	// features disabled by configuration are not advertised
+	assert.Equal(t, "2", NFManagement.Without("Shared-Data").Supported())
+	assert.Equal(t, "6", NFManagement.Supported())
+}
+
+func BenchmarkNegotiate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		supportedFeatures, err := NFDiscovery.Negotiate("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF")
+		if err != nil {
+			b.Fatal("Error Negotiate supported features:", err)
+		}
+		assert.Equal(b, "D2", supportedFeatures)
+	}
+}
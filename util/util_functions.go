@@ -1 +1,228 @@
 package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "nrf/data"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var ErrJSONPatchTestFailed = errors.New("JSON Patch test operation failed")
+
+func ApplyJSONPatch(document []byte, patch []PatchItem) (out []byte, err error) {
+	var doc interface{}
+	// unmarshal original document
+	err = json.Unmarshal(document, &doc)
+	if err != nil {
+		return nil, err
+	}
+	// apply patch operations in sequence
+	for _, item := range patch {
+		switch item.Op {
+		case "add":
+			doc, err = patchAdd(doc, item.Path, item.Value)
+		case "remove":
+			doc, _, err = patchRemove(doc, item.Path)
+		case "replace":
+			doc, _, err = patchRemove(doc, item.Path)
+			if err == nil {
+				doc, err = patchAdd(doc, item.Path, item.Value)
+			}
+		case "move":
+			var value interface{}
+			doc, value, err = patchRemove(doc, item.From)
+			if err == nil {
+				doc, err = patchAdd(doc, item.Path, value)
+			}
+		case "copy":
+			var value interface{}
+			value, err = patchGet(doc, item.From)
+			if err == nil {
+				doc, err = patchAdd(doc, item.Path, value)
+			}
+		case "test":
+			var value interface{}
+			value, err = patchGet(doc, item.Path)
+			if err == nil && !reflect.DeepEqual(value, normalizeJSON(item.Value)) {
+				err = fmt.Errorf("%w: %s", ErrJSONPatchTestFailed, item.Path)
+			}
+		default:
+			err = fmt.Errorf("JSON Patch operation %q is invalid", item.Op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	// marshal patched document
+	return json.Marshal(doc)
+}
+
+func parseJSONPointer(pointer string) (tokens []string, err error) {
+	if pointer == "" {
+		return tokens, err
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON Pointer %q is invalid", pointer)
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(token, "~1", "/")
+		token = strings.ReplaceAll(token, "~0", "~")
+		tokens = append(tokens, token)
+	}
+	return tokens, err
+}
+
+func parseArrayIndex(token string, length int, appendable bool) (index int, err error) {
+	if appendable && token == "-" {
+		return length, err
+	}
+	index, err = strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("JSON Pointer array index %q is invalid", token)
+	}
+	if index > length || (!appendable && index == length) {
+		return 0, fmt.Errorf("JSON Pointer array index %q out of bounds", token)
+	}
+	return index, nil
+}
+
+func patchGet(doc interface{}, path string) (value interface{}, err error) {
+	tokens, err := parseJSONPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	value = doc
+	for _, token := range tokens {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			v, exists := node[token]
+			if !exists {
+				return nil, fmt.Errorf("JSON Pointer %q not found", path)
+			}
+			value = v
+		case []interface{}:
+			index, err := parseArrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			value = node[index]
+		default:
+			return nil, fmt.Errorf("JSON Pointer %q not found", path)
+		}
+	}
+	return value, nil
+}
+
+func patchAdd(doc interface{}, path string, value interface{}) (out interface{}, err error) {
+	tokens, err := parseJSONPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	value = normalizeJSON(value)
+	// replace the whole document
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	// locate parent container
+	parent, err := patchParent(doc, tokens)
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		index, err := parseArrayIndex(last, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		node = append(node, nil)
+		copy(node[index+1:], node[index:])
+		node[index] = value
+		return patchSet(doc, tokens[:len(tokens)-1], node)
+	default:
+		return nil, fmt.Errorf("JSON Pointer %q parent not found", path)
+	}
+	return doc, nil
+}
+
+func patchRemove(doc interface{}, path string) (out interface{}, value interface{}, err error) {
+	tokens, err := parseJSONPointer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("JSON Patch can not remove the whole document")
+	}
+	value, err = patchGet(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	parent, err := patchParent(doc, tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		delete(node, last)
+	case []interface{}:
+		index, _ := parseArrayIndex(last, len(node), false)
+		node = append(node[:index:index], node[index+1:]...)
+		out, err = patchSet(doc, tokens[:len(tokens)-1], node)
+		return out, value, err
+	}
+	return doc, value, nil
+}
+
+func patchSet(doc interface{}, tokens []string, value interface{}) (out interface{}, err error) {
+	// arrays are re-allocated on insert and delete, so store them back into their parent
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parent, err := patchParent(doc, tokens)
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		index, err := parseArrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+	}
+	return doc, nil
+}
+
+func patchParent(doc interface{}, tokens []string) (parent interface{}, err error) {
+	if len(tokens) == 1 {
+		return doc, nil
+	}
+	var pointer string
+	for _, token := range tokens[:len(tokens)-1] {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		pointer += "/" + token
+	}
+	return patchGet(doc, pointer)
+}
+
+func normalizeJSON(value interface{}) (out interface{}) {
+	// round-trip through encoding/json so values compare with (and never alias) decoded documents
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	if err = json.Unmarshal(data, &out); err != nil {
+		return value
+	}
+	return out
+}
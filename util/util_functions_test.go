@@ -0,0 +1,68 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	. "nrf/data"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	document := []byte(`{"nfStatus":"REGISTERED","heartBeatTimer":60,"nfServices":[{"serviceInstanceId":"1"}]}`)
+	patch := []PatchItem{
+		{Op: "test", Path: "/nfStatus", Value: "REGISTERED"},
+		{Op: "replace", Path: "/nfStatus", Value: "SUSPENDED"},
+		{Op: "add", Path: "/nfServices/-", Value: map[string]interface{}{"serviceInstanceId": "2"}},
+		{Op: "copy", From: "/nfServices/0", Path: "/nfServices/0"},
+		{Op: "remove", Path: "/nfServices/1"},
+		{Op: "move", From: "/heartBeatTimer", Path: "/load"},
+	}
+	out, err := ApplyJSONPatch(document, patch)
+	if err != nil {
+		t.Fatal("Error Apply JSON Patch:", err)
+	}
+	var result map[string]interface{}
+	err = json.Unmarshal(out, &result)
+	if err != nil {
+		t.Fatal("Error Unmarshal JSON Patch result:", err)
+	}
+	assert.Equal(t, "SUSPENDED", result["nfStatus"])
+	assert.Equal(t, float64(60), result["load"])
+	assert.NotContains(t, result, "heartBeatTimer")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"serviceInstanceId": "1"},
+		map[string]interface{}{"serviceInstanceId": "2"},
+	}, result["nfServices"])
+}
+
+func TestApplyJSONPatchTestFailed(t *testing.T) {
+	document := []byte(`{"nfStatus":"REGISTERED"}`)
+	patch := []PatchItem{
+		{Op: "test", Path: "/nfStatus", Value: "SUSPENDED"},
+	}
+	_, err := ApplyJSONPatch(document, patch)
+	assert.True(t, errors.Is(err, ErrJSONPatchTestFailed))
+}
+
+func TestApplyJSONPatchPathNotFound(t *testing.T) {
+	document := []byte(`{"nfServices":[]}`)
+	patch := []PatchItem{
+		{Op: "replace", Path: "/nfServices/0", Value: "x"},
+	}
+	_, err := ApplyJSONPatch(document, patch)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkApplyJSONPatch(b *testing.B) {
+	document := []byte(`{"nfStatus":"REGISTERED","heartBeatTimer":60}`)
+	patch := []PatchItem{
+		{Op: "replace", Path: "/nfStatus", Value: "SUSPENDED"},
+	}
+	for i := 0; i < b.N; i++ {
+		_, err := ApplyJSONPatch(document, patch)
+		if err != nil {
+			b.Fatal("Error Apply JSON Patch:", err)
+		}
+	}
+}
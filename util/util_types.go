@@ -4,9 +4,32 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	. "nrf/conf"
+	. "nrf/data"
+	"nrf/features"
+	"regexp"
 	"strings"
 )
 
+var (
+	mccPattern              = regexp.MustCompile(`^[0-9]{3}$`)
+	mncPattern              = regexp.MustCompile(`^[0-9]{2,3}$`)
+	sdPattern               = regexp.MustCompile(`^[A-Fa-f0-9]{6}$`)
+	supiPattern             = regexp.MustCompile(`^(imsi-[0-9]{5,15}|nai-.+|gci-.+|gli-.+)$`)
+	gpsiPattern             = regexp.MustCompile(`^(msisdn-[0-9]{5,15}|extid-[^@]+@[^@]+)$`)
+	extGroupIdPattern       = regexp.MustCompile(`^extgroupid-[^@]+@[^@]+$`)
+	routingIndicatorPattern = regexp.MustCompile(`^[0-9]{1,4}$`)
+	rangeBoundPattern       = regexp.MustCompile(`^[0-9]+$`)
+	tacPattern              = regexp.MustCompile(`^([A-Fa-f0-9]{4}|[A-Fa-f0-9]{6})$`)
+	amfIdPattern            = regexp.MustCompile(`^[A-Fa-f0-9]{6}$`)
+	amfRegionIdPattern      = regexp.MustCompile(`^[A-Fa-f0-9]{2}$`)
+	amfSetIdPattern         = regexp.MustCompile(`^[0-3][A-Fa-f0-9]{2}$`)
+	nfSetIdPattern          = regexp.MustCompile(`(?i)^set[a-z0-9-]+\.[a-z0-9_]+set\.5gc\.(mnc[0-9]{2,3}\.mcc[0-9]{3}|nid[a-f0-9]{11})$`)
+	nfServiceSetIdPattern   = regexp.MustCompile(`(?i)^set[a-z0-9-]+\.sn[a-z0-9-]+\.nfi[a-f0-9-]+\.5gc\.(mnc[0-9]{2,3}\.mcc[0-9]{3}|nid[a-f0-9]{11})$`)
+	serviceNamePattern      = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	apiVersionInUriPattern  = regexp.MustCompile(`^v[0-9]+$`)
+	apiFullVersionPattern   = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(\.(alpha|beta)-[0-9]+)?(\+.*)?$`)
+)
+
 func CheckNFInstanceId(nfInstanceId string) (b bool, err error) {
 	b, err = true, nil
 	// parse NFInstanceId
@@ -95,3 +118,310 @@ func HandleHeartBeatTimer(heartBeatTimer *int) (err error) {
 	}
 	return err
 }
+
+func CheckServiceName(serviceName string) (b bool, err error) {
+	b, err = true, nil
+	// check ServiceName
+	if serviceName == "" {
+		b, err = false, errors.New("ServiceName is mandatory")
+		return b, err
+	}
+	if !serviceNamePattern.MatchString(serviceName) {
+		b, err = false, errors.New("ServiceName is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckScheme(scheme string) (b bool, err error) {
+	b, err = true, nil
+	// check Scheme
+	switch scheme {
+	case "http":
+	case "https":
+	case "":
+		b, err = false, errors.New("Scheme is mandatory")
+		return b, err
+	default:
+		b, err = false, errors.New("Scheme is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckPlmnId(plmnId PlmnId) (b bool, err error) {
+	b, err = true, nil
+	// check Mcc and Mnc
+	if !mccPattern.MatchString(plmnId.Mcc) {
+		b, err = false, errors.New("Mcc is invalid")
+		return b, err
+	}
+	if !mncPattern.MatchString(plmnId.Mnc) {
+		b, err = false, errors.New("Mnc is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckSnssai(snssai Snssai) (b bool, err error) {
+	b, err = true, nil
+	// check Sst and Sd
+	if snssai.Sst < 0 || snssai.Sst > 255 {
+		b, err = false, errors.New("Sst is invalid")
+		return b, err
+	}
+	if snssai.Sd != "" && !sdPattern.MatchString(snssai.Sd) {
+		b, err = false, errors.New("Sd is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckSupi(supi string) (b bool, err error) {
+	b, err = true, nil
+	// check Supi
+	if !supiPattern.MatchString(supi) {
+		b, err = false, errors.New("Supi is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckGpsi(gpsi string) (b bool, err error) {
+	b, err = true, nil
+	// check Gpsi
+	if !gpsiPattern.MatchString(gpsi) {
+		b, err = false, errors.New("Gpsi is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckExternalGroupId(externalGroupId string) (b bool, err error) {
+	b, err = true, nil
+	// check ExternalGroupId
+	if !extGroupIdPattern.MatchString(externalGroupId) {
+		b, err = false, errors.New("ExternalGroupId is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckRoutingIndicator(routingIndicator string) (b bool, err error) {
+	b, err = true, nil
+	// check RoutingIndicator
+	if !routingIndicatorPattern.MatchString(routingIndicator) {
+		b, err = false, errors.New("RoutingIndicator is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckIdentityRange(identityRange IdentityRange) (b bool, err error) {
+	b, err = true, nil
+	// check Pattern, which takes precedence over Start and End
+	if identityRange.Pattern != "" {
+		_, err = regexp.Compile(identityRange.Pattern)
+		if err != nil {
+			b = false
+			return b, err
+		}
+		return b, err
+	}
+	// check Start and End
+	b, err = CheckRangeBound(identityRange.Start)
+	if err != nil {
+		return b, err
+	}
+	b, err = CheckRangeBound(identityRange.End)
+	if err != nil {
+		return b, err
+	}
+	if CompareRangeBound(identityRange.Start, identityRange.End) > 0 {
+		b, err = false, errors.New("IdentityRange start is greater than end")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckRangeBound(bound string) (b bool, err error) {
+	b, err = true, nil
+	// check numeric range bound
+	if !rangeBoundPattern.MatchString(bound) {
+		b, err = false, errors.New("IdentityRange requires numeric start and end, or pattern")
+		return b, err
+	}
+	return b, err
+}
+
+func CompareRangeBound(a string, b string) int {
+	// numeric strings compare by length first, then lexicographically
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func CheckTai(tai Tai) (b bool, err error) {
+	b, err = true, nil
+	// check PlmnId and Tac
+	b, err = CheckPlmnId(tai.PlmnId)
+	if err != nil {
+		return b, err
+	}
+	if !tacPattern.MatchString(tai.Tac) {
+		b, err = false, errors.New("Tac is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckTaiRange(taiRange TaiRange) (b bool, err error) {
+	b, err = true, nil
+	// check PlmnId
+	b, err = CheckPlmnId(taiRange.PlmnId)
+	if err != nil {
+		return b, err
+	}
+	// check TacRangeList, pattern takes precedence over start and end
+	for _, v := range taiRange.TacRangeList {
+		if v.Pattern != "" {
+			_, err = regexp.Compile(v.Pattern)
+			if err != nil {
+				b = false
+				return b, err
+			}
+			continue
+		}
+		if !tacPattern.MatchString(v.Start) || !tacPattern.MatchString(v.End) || len(v.Start) != len(v.End) {
+			b, err = false, errors.New("TacRange requires start and end of the same Tac format, or pattern")
+			return b, err
+		}
+		if strings.ToUpper(v.Start) > strings.ToUpper(v.End) {
+			b, err = false, errors.New("TacRange start is greater than end")
+			return b, err
+		}
+	}
+	return b, err
+}
+
+func CheckGuami(guami Guami) (b bool, err error) {
+	b, err = true, nil
+	// check PlmnId and AmfId
+	b, err = CheckPlmnId(guami.PlmnId)
+	if err != nil {
+		return b, err
+	}
+	if !amfIdPattern.MatchString(guami.AmfId) {
+		b, err = false, errors.New("AmfId is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckAmfRegionId(amfRegionId string) (b bool, err error) {
+	b, err = true, nil
+	// check AmfRegionId
+	if !amfRegionIdPattern.MatchString(amfRegionId) {
+		b, err = false, errors.New("AmfRegionId is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckAmfSetId(amfSetId string) (b bool, err error) {
+	b, err = true, nil
+	// check AmfSetId, 10 bits encoded in 3 hex digits
+	if !amfSetIdPattern.MatchString(amfSetId) {
+		b, err = false, errors.New("AmfSetId is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckNfSetId(nfSetId string) (b bool, err error) {
+	b, err = true, nil
+	// check NfSetId, set<Set ID>.<nftype>set.5gc.mnc<MNC>.mcc<MCC>
+	if !nfSetIdPattern.MatchString(nfSetId) {
+		b, err = false, errors.New("NfSetId is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckNfServiceSetId(nfServiceSetId string) (b bool, err error) {
+	b, err = true, nil
+	// check NfServiceSetId, set<Set ID>.sn<Service Name>.nfi<NF Instance ID>.5gc.mnc<MNC>.mcc<MCC>
+	if !nfServiceSetIdPattern.MatchString(nfServiceSetId) {
+		b, err = false, errors.New("NfServiceSetId is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckSupportedFeatures(supportedFeatures string) (b bool, err error) {
+	b, err = true, nil
+	// check SupportedFeatures hex encoded bitmask
+	_, err = features.Parse(supportedFeatures)
+	if err != nil {
+		b = false
+		return b, err
+	}
+	return b, err
+}
+
+func CheckNFServiceVersions(versions []NFServiceVersion) (b bool, err error) {
+	b, err = true, nil
+	// check NFServiceVersions
+	if len(versions) == 0 {
+		b, err = false, errors.New("Versions is mandatory")
+		return b, err
+	}
+	for _, v := range versions {
+		if !apiVersionInUriPattern.MatchString(v.ApiVersionInUri) {
+			b, err = false, errors.New("ApiVersionInUri is invalid")
+			return b, err
+		}
+		if !apiFullVersionPattern.MatchString(v.ApiFullVersion) {
+			b, err = false, errors.New("ApiFullVersion is invalid")
+			return b, err
+		}
+	}
+	return b, err
+}
+
+func CheckAllowedNfDomain(allowedNfDomain string) (b bool, err error) {
+	b, err = true, nil
+	// check AllowedNfDomain, a regular expression of FQDNs
+	_, err = regexp.Compile(allowedNfDomain)
+	if err != nil {
+		b = false
+		return b, err
+	}
+	return b, err
+}
+
+func CheckApiVersion(version string) (b bool, err error) {
+	b, err = true, nil
+	// check API version in URI or full API version
+	if !apiVersionInUriPattern.MatchString(version) && !apiFullVersionPattern.MatchString(version) {
+		b, err = false, errors.New("ApiVersion is invalid")
+		return b, err
+	}
+	return b, err
+}
+
+func CheckNFInfoTypes(nfType string, infoTypes []string) (b bool, err error) {
+	b, err = true, nil
+	// check NFInfo match NFType
+	for _, v := range infoTypes {
+		if v != nfType {
+			b, err = false, errors.New("NFInfo of "+v+" not match NFType "+nfType)
+			return b, err
+		}
+	}
+	return b, err
+}
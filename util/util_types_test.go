@@ -3,6 +3,7 @@ package util
 import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	. "nrf/data"
 	"strings"
 	"testing"
 )
@@ -102,3 +103,214 @@ func BenchmarkCheckHeartBeatTimer(b *testing.B) {
 		}
 	}
 }
+
+func TestCheckServiceName(t *testing.T) {
+	serviceName := "namf-comm"
+	b, err := CheckServiceName(serviceName)
+	if b != true || err != nil {
+		t.Fatal("Error Check ServiceName:", err)
+	}
+	b, err = CheckServiceName("")
+	if b != false || err == nil {
+		t.Fatal("Error Check empty ServiceName:", err)
+	}
+}
+
+func BenchmarkCheckServiceName(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		serviceName := "namf-comm"
+		r, err := CheckServiceName(serviceName)
+		if r != true || err != nil {
+			b.Fatal("Error Check ServiceName:", err)
+		}
+	}
+}
+
+func TestCheckScheme(t *testing.T) {
+	scheme := "https"
+	b, err := CheckScheme(scheme)
+	if b != true || err != nil {
+		t.Fatal("Error Check Scheme:", err)
+	}
+	b, err = CheckScheme("ftp")
+	if b != false || err == nil {
+		t.Fatal("Error Check invalid Scheme:", err)
+	}
+}
+
+func TestCheckNFServiceVersions(t *testing.T) {
+	versions := []NFServiceVersion{{ApiVersionInUri: "v1", ApiFullVersion: "1.2.0"}}
+	b, err := CheckNFServiceVersions(versions)
+	if b != true || err != nil {
+		t.Fatal("Error Check NFServiceVersions:", err)
+	}
+	b, err = CheckNFServiceVersions(nil)
+	if b != false || err == nil {
+		t.Fatal("Error Check empty NFServiceVersions:", err)
+	}
+	b, err = CheckNFServiceVersions([]NFServiceVersion{{ApiVersionInUri: "1", ApiFullVersion: "1.2.0"}})
+	if b != false || err == nil {
+		t.Fatal("Error Check invalid NFServiceVersions:", err)
+	}
+}
+
+func TestCheckNFInfoTypes(t *testing.T) {
+	b, err := CheckNFInfoTypes("AMF", []string{"AMF"})
+	if b != true || err != nil {
+		t.Fatal("Error Check NFInfoTypes:", err)
+	}
+	b, err = CheckNFInfoTypes("AMF", []string{"SMF"})
+	if b != false || err == nil {
+		t.Fatal("Error Check mismatched NFInfoTypes:", err)
+	}
+}
+
+func TestCheckSupportedFeatures(t *testing.T) {
+	b, err := CheckSupportedFeatures("1F")
+	if b != true || err != nil {
+		t.Fatal("Error Check SupportedFeatures:", err)
+	}
+	b, err = CheckSupportedFeatures("ipv4")
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckPlmnId(t *testing.T) {
+	b, err := CheckPlmnId(PlmnId{Mcc: "460", Mnc: "00"})
+	if b != true || err != nil {
+		t.Fatal("Error Check PlmnId:", err)
+	}
+	b, err = CheckPlmnId(PlmnId{Mcc: "46", Mnc: "000"})
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckPlmnId(PlmnId{Mcc: "460", Mnc: "0"})
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckSnssai(t *testing.T) {
+	b, err := CheckSnssai(Snssai{Sst: 1, Sd: "00000A"})
+	if b != true || err != nil {
+		t.Fatal("Error Check Snssai:", err)
+	}
+	b, err = CheckSnssai(Snssai{Sst: 256})
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckSnssai(Snssai{Sst: 1, Sd: "0A"})
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckSupi(t *testing.T) {
+	b, err := CheckSupi("imsi-460001234567890")
+	if b != true || err != nil {
+		t.Fatal("Error Check Supi:", err)
+	}
+	b, err = CheckSupi("460001234567890")
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckGpsi(t *testing.T) {
+	b, err := CheckGpsi("msisdn-8613800000000")
+	if b != true || err != nil {
+		t.Fatal("Error Check Gpsi:", err)
+	}
+	b, err = CheckGpsi("msisdn-86abc")
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckIdentityRange(t *testing.T) {
+	b, err := CheckIdentityRange(IdentityRange{Start: "460000000000000", End: "460009999999999"})
+	if b != true || err != nil {
+		t.Fatal("Error Check IdentityRange:", err)
+	}
+	b, err = CheckIdentityRange(IdentityRange{Pattern: "^imsi-46001[0-9]{10}$"})
+	if b != true || err != nil {
+		t.Fatal("Error Check IdentityRange:", err)
+	}
+	b, err = CheckIdentityRange(IdentityRange{Start: "99", End: "100"})
+	if b != true || err != nil {
+		t.Fatal("Error Check IdentityRange:", err)
+	}
+	b, err = CheckIdentityRange(IdentityRange{Start: "100", End: "99"})
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckIdentityRange(IdentityRange{Start: "100"})
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckIdentityRange(IdentityRange{Pattern: "imsi-(46001"})
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckTaiRange(t *testing.T) {
+	plmnId := PlmnId{Mcc: "460", Mnc: "00"}
+	b, err := CheckTai(Tai{PlmnId: plmnId, Tac: "00a1"})
+	if b != true || err != nil {
+		t.Fatal("Error Check Tai:", err)
+	}
+	b, err = CheckTai(Tai{PlmnId: plmnId, Tac: "00001"})
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckTaiRange(TaiRange{PlmnId: plmnId, TacRangeList: []TacRange{{Start: "000100", End: "0001ff"}, {Pattern: "^00[0-9]{2}$"}}})
+	if b != true || err != nil {
+		t.Fatal("Error Check TaiRange:", err)
+	}
+	b, err = CheckTaiRange(TaiRange{PlmnId: plmnId, TacRangeList: []TacRange{{Start: "0001FF", End: "000100"}}})
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckTaiRange(TaiRange{PlmnId: plmnId, TacRangeList: []TacRange{{Start: "0001", End: "000100"}}})
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckGuami(t *testing.T) {
+	b, err := CheckGuami(Guami{PlmnId: PlmnId{Mcc: "460", Mnc: "00"}, AmfId: "01a041"})
+	if b != true || err != nil {
+		t.Fatal("Error Check Guami:", err)
+	}
+	b, err = CheckGuami(Guami{PlmnId: PlmnId{Mcc: "460", Mnc: "00"}, AmfId: "0041"})
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckAmfSetId("3FF")
+	if b != true || err != nil {
+		t.Fatal("Error Check AmfSetId:", err)
+	}
+	b, err = CheckAmfSetId("400")
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckAmfRegionId("0g")
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckApiVersion(t *testing.T) {
+	for _, v := range []string{"v1", "1.0.0", "2.1.0.alpha-1"} {
+		b, err := CheckApiVersion(v)
+		if b != true || err != nil {
+			t.Fatal("Error Check ApiVersion:", err)
+		}
+	}
+	b, err := CheckApiVersion("1.0")
+	assert.False(t, b)
+	assert.Error(t, err)
+}
+
+func TestCheckNfSetId(t *testing.T) {
+	b, err := CheckNfSetId("setxyz.smfset.5gc.mnc012.mcc345")
+	if b != true || err != nil {
+		t.Fatal("Error Check NfSetId:", err)
+	}
+	b, err = CheckNfSetId("smfset.5gc.mnc012.mcc345")
+	assert.False(t, b)
+	assert.Error(t, err)
+	b, err = CheckNfServiceSetId("setxyz.snnsmf-pdusession.nfi54804518-4191-46b3-955c-ac631f953ed8.5gc.mnc012.mcc345")
+	if b != true || err != nil {
+		t.Fatal("Error Check NfServiceSetId:", err)
+	}
+	b, err = CheckNfServiceSetId("setxyz.smfset.5gc.mnc012.mcc345")
+	assert.False(t, b)
+	assert.Error(t, err)
+}